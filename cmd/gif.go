@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+
+	"zontengine/internal/export"
+)
+
+// exportGIF renders a full turn of the configured model to an animated GIF.
+func exportGIF(args []string) error {
+	flags := flag.NewFlagSet("gif", flag.ContinueOnError)
+	output := flags.String("o", "render.gif", "output file")
+	frames := flags.Int("frames", 36, "frames per revolution")
+	delay := flags.Duration("delay", export.DefaultGIFDelay, "time each frame is shown")
+	paletteName := flags.String("palette", "auto", "palette: auto, plan9, websafe or gray")
+	dither := flags.Bool("dither", false, "dither colors missing from the palette")
+	image := addImageFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	imageOpts, err := image.options()
+	if err != nil {
+		return err
+	}
+	opts := export.GIFOptions{ImageOptions: imageOpts, Delay: *delay, Dither: *dither}
+	if opts.Palette, err = export.ParsePalette(*paletteName); err != nil {
+		return err
+	}
+
+	model, renderer, err := image.scene.setup(imageOpts.CellAspect())
+	if err != nil {
+		return err
+	}
+	turntable, err := renderer.RenderTurntable(model, *frames)
+	if err != nil {
+		return err
+	}
+	return export.SaveGIF(*output, turntable, opts)
+}
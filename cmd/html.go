@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+
+	"zontengine/internal/export"
+	"zontengine/internal/render"
+)
+
+// exportHTML writes a still picture or a turntable animation of the
+// configured model as a standalone HTML page.
+func exportHTML(args []string) error {
+	flags := flag.NewFlagSet("html", flag.ContinueOnError)
+	output := flags.String("o", "render.html", "output file")
+	frames := flags.Int("frames", 1, "frames per revolution (1 = still picture)")
+	angle := flags.Float64("angle", 0, "spin angle in degrees of a still picture")
+	delay := flags.Duration("delay", export.DefaultGIFDelay, "time each frame is shown")
+	title := flags.String("title", "", "page title")
+	mono := flags.Bool("mono", false, "draw every cell in the foreground color")
+	fg := flags.String("fg", "#cccccc", "foreground color for cells without one")
+	bg := flags.String("bg", "#000000", "page background color")
+	scene := addSceneFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := export.HTMLOptions{Title: *title, Monochrome: *mono, Delay: *delay}
+	var err error
+	if opts.Foreground, err = parseHexColor(*fg); err != nil {
+		return err
+	}
+	if opts.Background, err = parseHexColor(*bg); err != nil {
+		return err
+	}
+
+	model, renderer, err := scene.setup(export.HTMLCellAspect)
+	if err != nil {
+		return err
+	}
+
+	var pictures []*render.Frame
+	if *frames > 1 {
+		pictures, err = renderer.RenderTurntable(model, *frames)
+	} else {
+		var frame *render.Frame
+		frame, err = renderAt(renderer, model, *angle)
+		pictures = []*render.Frame{frame}
+	}
+	if err != nil {
+		return err
+	}
+	return export.SaveHTML(*output, pictures, opts)
+}
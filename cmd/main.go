@@ -1,50 +1,111 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"zontengine/internal/config"
-	"zontengine/internal/matrix"
 	"zontengine/internal/render"
+	"zontengine/internal/screen"
+	"zontengine/internal/terminal"
 )
 
+const usage = "Incorrect arguments. Usage: program render|png|gif|html|svg|record [flags] (program <command> -h lists the flags)"
+
 func main() {
-	if len(os.Args) > 1 && os.Args[1] == "render" {
-		err := renderFromConfig()
-		if err != nil {
-			log.Fatal("Render error: ", err)
-		}
-	} else {
-		log.Fatal("Incorrect arguments. Usage: program render")
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "render":
+		err = renderFromConfig(os.Args[2:])
+	case "png":
+		err = exportPNG(os.Args[2:])
+	case "gif":
+		err = exportGIF(os.Args[2:])
+	case "html":
+		err = exportHTML(os.Args[2:])
+	case "svg":
+		err = exportSVG(os.Args[2:])
+	case "record":
+		err = recordCast(os.Args[2:])
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatal("Render error: ", err)
 	}
 	//tui.Run()
 }
 
-func renderFromConfig() error {
+func renderFromConfig(args []string) error {
+	var opts render.Options
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.IntVar(&opts.Frames, "frames", 0, "stop after this many frames (0 = unlimited)")
+	flags.DurationVar(&opts.Duration, "duration", 0, "stop after this long, e.g. 10s (0 = unlimited)")
+	flags.IntVar(&opts.FPS, "fps", render.DefaultFPS, "frames drawn per second")
+	view := addViewFlags(flags)
+	colorMode := flags.String("color", "auto", "color output: auto, none, 16, 256 or truecolor")
+	fit := flags.Bool("fit", false, "size the image to the terminal and follow resizes")
+	fullRedraw := flags.Bool("full-redraw", false, "rewrite the whole screen every frame")
+	showStats := flags.Bool("stats", false, "print output size statistics on exit")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	colorProfile, err := screen.ParseColorProfile(*colorMode)
+	if err != nil {
+		return err
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
-	if cfg.ModelFile == "" {
-		return fmt.Errorf("no model selected in configuration - run TUI interface first")
+	verts, err := loadModel(cfg)
+	if err != nil {
+		return err
 	}
 
-	modelFile := "models/" + cfg.ModelFile
-	if _, err := os.Stat(modelFile); os.IsNotExist(err) {
-		return fmt.Errorf("model file not found: %s (check models/ directory)", modelFile)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	width, height := cfg.Width, cfg.Height
+	if *fit || cfg.FitTerminal {
+		size, err := terminal.GetSize(os.Stdout)
+		if err != nil {
+			return fmt.Errorf("fitting to terminal: %w", err)
+		}
+		width, height = size.Fit()
+		opts.Resize = terminal.WatchResize(ctx, os.Stdout)
 	}
 
-	matrix := matrix.NewMatrix(cfg.Width, cfg.Height)
-	renderer := render.NewRender(matrix)
+	opts.FullScreen = terminal.IsTerminal(os.Stdout)
 
-	verts, err := render.LoadOBJ(modelFile)
+	renderer, err := view.newRenderer(width, height, terminalCellAspect(cfg))
 	if err != nil {
-		return fmt.Errorf("loading OBJ %s: %w", modelFile, err)
+		return err
 	}
+	renderer.SetColorProfile(colorProfile)
+	renderer.SetFullRedraw(*fullRedraw)
 
-	renderer.Render(verts)
-	return nil
+	err = renderer.Render(ctx, verts, opts)
+	if *showStats {
+		stats := renderer.DrawStats()
+		fmt.Fprintf(os.Stderr, "%d frames (%d full), %.0f bytes/frame on average, %d bytes total\n",
+			stats.Frames, stats.FullFrames, stats.AverageBytes(), stats.TotalBytes)
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
 }
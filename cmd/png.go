@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"math"
+
+	"zontengine/internal/config"
+	"zontengine/internal/export"
+	"zontengine/internal/mesh"
+	"zontengine/internal/render"
+	"zontengine/internal/rotate"
+)
+
+// sceneFlags select the size of an exported picture; the model and the
+// view come from the configuration and the view flags.
+type sceneFlags struct {
+	cols *int
+	rows *int
+	view *viewFlags
+}
+
+func addSceneFlags(flags *flag.FlagSet) *sceneFlags {
+	return &sceneFlags{
+		cols: flags.Int("cols", 0, "image width in cells (0 = config width)"),
+		rows: flags.Int("rows", 0, "image height in cells (0 = config height)"),
+		view: addViewFlags(flags),
+	}
+}
+
+// setup loads the configured model and creates a renderer sized by the
+// flags, with cells of the given shape.
+func (f *sceneFlags) setup(cellAspect float64) (*mesh.Mesh, *render.Render, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading config: %w", err)
+	}
+	model, err := loadModel(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	width, height := cfg.Width, cfg.Height
+	if *f.cols > 0 {
+		width = *f.cols
+	}
+	if *f.rows > 0 {
+		height = *f.rows
+	}
+	renderer, err := f.view.newRenderer(width, height, cellAspect)
+	if err != nil {
+		return nil, nil, err
+	}
+	return model, renderer, nil
+}
+
+// imageFlags are the settings shared by the image exporters.
+type imageFlags struct {
+	mode  *string
+	scale *int
+	mono  *bool
+	fg    *string
+	bg    *string
+	scene *sceneFlags
+}
+
+func addImageFlags(flags *flag.FlagSet) *imageFlags {
+	return &imageFlags{
+		mode:  flags.String("image", "glyphs", "image content: glyphs or pixels"),
+		scale: flags.Int("scale", 1, "pixel scale factor"),
+		mono:  flags.Bool("mono", false, "draw every cell in the foreground color"),
+		fg:    flags.String("fg", "#cccccc", "foreground color for cells without one"),
+		bg:    flags.String("bg", "#000000", "background color"),
+		scene: addSceneFlags(flags),
+	}
+}
+
+func (f *imageFlags) options() (export.ImageOptions, error) {
+	opts := export.ImageOptions{Scale: *f.scale, Monochrome: *f.mono}
+	var err error
+	if opts.Mode, err = export.ParseImageMode(*f.mode); err != nil {
+		return opts, err
+	}
+	if opts.Foreground, err = parseHexColor(*f.fg); err != nil {
+		return opts, err
+	}
+	if opts.Background, err = parseHexColor(*f.bg); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// exportPNG renders one frame of the configured model to a PNG file.
+func exportPNG(args []string) error {
+	flags := flag.NewFlagSet("png", flag.ContinueOnError)
+	output := flags.String("o", "render.png", "output file")
+	angle := flags.Float64("angle", 0, "spin angle in degrees")
+	image := addImageFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts, err := image.options()
+	if err != nil {
+		return err
+	}
+	model, renderer, err := image.scene.setup(opts.CellAspect())
+	if err != nil {
+		return err
+	}
+
+	frame, err := renderAt(renderer, model, *angle)
+	if err != nil {
+		return err
+	}
+	return export.SavePNG(*output, frame, opts)
+}
+
+// renderAt renders the model turned by angle degrees; see spunOrientation.
+func renderAt(renderer *render.Render, model *mesh.Mesh, angle float64) (*render.Frame, error) {
+	return renderer.RenderFrame(model, spunOrientation(renderer, angle), nil)
+}
+
+// spunOrientation returns the renderer's orientation turned by angle
+// degrees around the vertical axis.
+func spunOrientation(renderer *render.Render, angle float64) rotate.Quaternion {
+	spin := rotate.FromAxisAngle(0, 1, 0, angle*math.Pi/180)
+	return spin.Mul(renderer.GetOrientation())
+}
+
+// parseHexColor reads a "#rrggbb" color.
+func parseHexColor(s string) (color.Color, error) {
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil || len(s) != 7 {
+		return nil, fmt.Errorf("bad color %q (want #rrggbb)", s)
+	}
+	return color.RGBA{r, g, b, 0xff}, nil
+}
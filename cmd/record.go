@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"zontengine/internal/config"
+	"zontengine/internal/export"
+	"zontengine/internal/render"
+	"zontengine/internal/screen"
+)
+
+const defaultRecordDuration = 10 * time.Second
+
+// recordCast renders the configured model for a fixed time into an
+// asciicast v2 file.
+func recordCast(args []string) error {
+	opts := render.Options{Duration: defaultRecordDuration}
+	flags := flag.NewFlagSet("record", flag.ContinueOnError)
+	output := flags.String("o", "render.cast", "output file")
+	flags.DurationVar(&opts.Duration, "duration", opts.Duration, "recording length")
+	flags.IntVar(&opts.FPS, "fps", render.DefaultFPS, "frames drawn per second")
+	colorMode := flags.String("color", "truecolor", "color output: auto, none, 16, 256 or truecolor")
+	title := flags.String("title", "", "title shown by the player")
+	view := addViewFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if opts.Duration <= 0 {
+		return errors.New("recording needs a positive -duration")
+	}
+
+	colorProfile, err := screen.ParseColorProfile(*colorMode)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	model, err := loadModel(cfg)
+	if err != nil {
+		return err
+	}
+	renderer, err := view.newRenderer(cfg.Width, cfg.Height, terminalCellAspect(cfg))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	buffered := bufio.NewWriter(file)
+
+	recorder, err := export.NewCastRecorder(buffered, export.CastHeader{
+		Width:  cfg.Width,
+		Height: cfg.Height + 1,
+		Title:  *title,
+		Env:    map[string]string{"TERM": "xterm-256color"},
+	})
+	if err != nil {
+		return err
+	}
+	renderer.SetOutput(recorder)
+	renderer.SetColorProfile(colorProfile)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// An interrupted recording is still a valid file, just shorter.
+	err = renderer.Render(ctx, model, opts)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"zontengine/internal/export"
+	"zontengine/internal/render"
+)
+
+// exportSVG renders the configured model to an SVG file as polygons or as
+// text.
+func exportSVG(args []string) error {
+	flags := flag.NewFlagSet("svg", flag.ContinueOnError)
+	output := flags.String("o", "render.svg", "output file")
+	content := flags.String("content", "polygons", "picture content: polygons or text")
+	angle := flags.Float64("angle", 0, "spin angle in degrees")
+	mono := flags.Bool("mono", false, "draw everything in the foreground color")
+	fg := flags.String("fg", "#cccccc", "foreground color for cells without one")
+	bg := flags.String("bg", "#000000", "background color")
+	scene := addSceneFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := export.SVGOptions{Monochrome: *mono}
+	var err error
+	if opts.Foreground, err = parseHexColor(*fg); err != nil {
+		return err
+	}
+	if opts.Background, err = parseHexColor(*bg); err != nil {
+		return err
+	}
+
+	model, renderer, err := scene.setup(export.SVGCellAspect)
+	if err != nil {
+		return err
+	}
+
+	switch *content {
+	case "text":
+		frame, err := renderAt(renderer, model, *angle)
+		if err != nil {
+			return err
+		}
+		return export.SaveSVGText(*output, frame, opts)
+	case "polygons":
+		switch renderer.GetDrawStyle() {
+		case render.Points:
+			return errors.New("polygons cannot show the points style; use -content text")
+		case render.Wireframe:
+			opts.Outline = true
+		}
+		polygons, err := renderer.RenderPolygons(model, spunOrientation(renderer, *angle), nil)
+		if err != nil {
+			return err
+		}
+		cols, rows := renderer.GetSize()
+		return export.SaveSVGPolygons(*output, cols, rows, polygons, opts)
+	}
+	return fmt.Errorf("unknown picture content %q (want polygons or text)", *content)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"zontengine/internal/canvas"
+	"zontengine/internal/config"
+	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+	"zontengine/internal/render"
+	"zontengine/internal/terminal"
+)
+
+// viewFlags are the drawing settings shared by every subcommand.
+type viewFlags struct {
+	noCache    *bool
+	pixels     *string
+	style      *string
+	depth      *string
+	cellAspect *float64
+}
+
+func addViewFlags(flags *flag.FlagSet) *viewFlags {
+	return &viewFlags{
+		noCache:    flags.Bool("no-cache", false, "disable the renderer caches"),
+		pixels:     flags.String("pixels", "cell", "pixel mode: cell, half, quadrant or braille"),
+		style:      flags.String("style", "solid", "draw style: solid, wireframe or points"),
+		depth:      flags.String("depth", "buffer", "hidden surface removal: buffer (per-cell depth test) or painter (sorted triangles)"),
+		cellAspect: flags.Float64("cell-aspect", 0, "cell width divided by height (0 = automatic)"),
+	}
+}
+
+// newRenderer creates a renderer for a cols x rows image with the view
+// settings; cellAspect applies unless -cell-aspect was given.
+func (v *viewFlags) newRenderer(cols, rows int, cellAspect float64) (*render.Render, error) {
+	pixelMode, err := canvas.ParseMode(*v.pixels)
+	if err != nil {
+		return nil, err
+	}
+	drawStyle, err := render.ParseDrawStyle(*v.style)
+	if err != nil {
+		return nil, err
+	}
+	depthMode, err := render.ParseDepthMode(*v.depth)
+	if err != nil {
+		return nil, err
+	}
+
+	renderer := render.NewRender(matrix.NewMatrix(cols, rows))
+	if *v.noCache {
+		renderer.DisableCache()
+	}
+	renderer.SetPixelMode(pixelMode)
+	renderer.SetDrawStyle(drawStyle)
+	renderer.SetDepthMode(depthMode)
+	if *v.cellAspect > 0 {
+		cellAspect = *v.cellAspect
+	}
+	renderer.SetCellAspect(cellAspect)
+	return renderer, nil
+}
+
+// loadModel loads the model selected in the configuration from models/.
+func loadModel(cfg config.Config) (*mesh.Mesh, error) {
+	if cfg.ModelFile == "" {
+		return nil, fmt.Errorf("no model selected in configuration - run TUI interface first")
+	}
+
+	modelFile := "models/" + cfg.ModelFile
+	if _, err := os.Stat(modelFile); os.IsNotExist(err) {
+		return nil, fmt.Errorf("model file not found: %s (check models/ directory)", modelFile)
+	}
+
+	model, err := render.LoadModel(modelFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading model %s: %w", modelFile, err)
+	}
+	return model, nil
+}
+
+// terminalCellAspect picks the cell aspect ratio from the config or the
+// terminal, in that order; 0 leaves the renderer default.
+func terminalCellAspect(cfg config.Config) float64 {
+	if cfg.CellAspect > 0 {
+		return cfg.CellAspect
+	}
+	if detected, ok := terminal.DetectCellAspect(os.Stdout); ok {
+		return detected
+	}
+	return 0
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+)
+
+// keys returns the keys of c from the most to the least recently used.
+func keys[K comparable, V any](c *LRU[K, V]) []K {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var keys []K
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(*entry[K, V]).key)
+	}
+	return keys
+}
+
+func sameKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU[string, int](3)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+
+	// Reading a and updating b leave c as the least recently used.
+	if value, ok := c.Get("a"); !ok || value != 1 {
+		t.Fatalf("Get(a) = %d, %v; want 1, true", value, ok)
+	}
+	c.Put("b", 20)
+	c.Put("d", 4)
+
+	if got, want := keys(c), []string{"d", "b", "a"}; !sameKeys(got, want) {
+		t.Fatalf("keys are %v, want %v", got, want)
+	}
+	if _, ok := c.Get("c"); ok {
+		t.Error("c was not evicted")
+	}
+	if value, _ := c.Get("b"); value != 20 {
+		t.Errorf("Get(b) = %d, want the updated 20", value)
+	}
+
+	c.Put("e", 5)
+	c.Put("f", 6)
+	if got, want := keys(c), []string{"f", "e", "b"}; !sameKeys(got, want) {
+		t.Errorf("keys are %v, want %v", got, want)
+	}
+	if stats := c.Stats(); stats.Evictions != 3 || stats.Size != 3 || stats.Capacity != 3 {
+		t.Errorf("stats are %+v, want 3 evictions of a full cache of 3", stats)
+	}
+}
+
+func TestLRUResize(t *testing.T) {
+	c := NewLRU[string, int](4)
+	for i, key := range []string{"a", "b", "c", "d"} {
+		c.Put(key, i)
+	}
+	c.Get("a")
+
+	// Shrinking drops the least recently used entries first.
+	c.Resize(2)
+	if got, want := keys(c), []string{"a", "d"}; !sameKeys(got, want) {
+		t.Fatalf("keys are %v after shrinking, want %v", got, want)
+	}
+	if stats := c.Stats(); stats.Evictions != 2 || stats.Capacity != 2 {
+		t.Errorf("stats are %+v, want 2 evictions and a capacity of 2", stats)
+	}
+
+	// Growing keeps the entries and makes room for more.
+	c.Resize(3)
+	c.Put("e", 4)
+	if got, want := keys(c), []string{"e", "a", "d"}; !sameKeys(got, want) {
+		t.Errorf("keys are %v after growing, want %v", got, want)
+	}
+
+	// A capacity of zero empties the cache and stores nothing more.
+	c.Resize(0)
+	c.Put("f", 5)
+	if c.Len() != 0 {
+		t.Errorf("disabled cache holds %v", keys(c))
+	}
+}
+
+func TestLRUDisabled(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		c := NewLRU[int, int](capacity)
+		c.Put(1, 1)
+		if _, ok := c.Get(1); ok || c.Len() != 0 {
+			t.Errorf("cache of capacity %d stored an entry", capacity)
+		}
+	}
+}
+
+func TestLRUStats(t *testing.T) {
+	c := NewLRU[int, int](2)
+	if rate := c.Stats().HitRate(); rate != 0 {
+		t.Errorf("hit rate before any lookup is %v, want 0", rate)
+	}
+
+	c.Put(1, 1)
+	c.Get(1)
+	c.Get(1)
+	c.Get(1)
+	c.Get(2)
+
+	stats := c.Stats()
+	want := Stats{Hits: 3, Misses: 1, Size: 1, Capacity: 2}
+	if stats != want {
+		t.Errorf("stats are %+v, want %+v", stats, want)
+	}
+	if rate := stats.HitRate(); rate != 0.75 {
+		t.Errorf("hit rate is %v, want 0.75", rate)
+	}
+
+	// Clearing drops the entries but not the counters.
+	c.Clear()
+	if stats := c.Stats(); stats.Size != 0 || stats.Hits != 3 || stats.Misses != 1 {
+		t.Errorf("stats are %+v after Clear, want no entries and the same counts", stats)
+	}
+	if _, ok := c.Get(1); ok {
+		t.Error("Get found an entry after Clear")
+	}
+}
+
+// TestLRUConcurrent is meant for go test -race.
+func TestLRUConcurrent(t *testing.T) {
+	const workers, lookups = 8, 1000
+	c := NewLRU[int, int](16)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < lookups; i++ {
+				key := (worker + i) % 32
+				if value, ok := c.Get(key); ok && value != key*key {
+					t.Errorf("Get(%d) = %d, want %d", key, value, key*key)
+					return
+				}
+				c.Put(key, key*key)
+				if i%250 == 0 {
+					c.Resize(8 + i%16)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	stats := c.Stats()
+	if stats.Hits+stats.Misses != workers*lookups {
+		t.Errorf("counted %d lookups, want %d", stats.Hits+stats.Misses, workers*lookups)
+	}
+	if stats.Size > stats.Capacity || stats.Size != len(keys(c)) {
+		t.Errorf("cache holds %d entries with a capacity of %d", stats.Size, stats.Capacity)
+	}
+}
@@ -0,0 +1,138 @@
+package camera
+
+/**
+ * Describes the viewer of a scene: where it stands, where it looks and how
+ * the 3D world is projected onto the 2D terminal grid.
+ *
+ * @param Position    eye position in world space
+ * @param Target      point the camera looks at
+ * @param Up          approximate up direction used to build the view basis
+ * @param FOV         vertical field of view in degrees (perspective only)
+ * @param Near        distance to the near clipping plane
+ * @param Far         distance to the far clipping plane
+ * @param OrthoHeight visible height in world units (orthographic only)
+ * @param Projection  perspective or orthographic projection
+ *
+ * Projected vertices are returned in normalized device coordinates where
+ * x and y lie in [-1, 1] and z grows with distance from the eye, so a smaller
+ * z is always closer to the viewer.
+ */
+
+import (
+	"math"
+
+	"zontengine/internal/linalg"
+)
+
+type Projection int
+
+const (
+	Perspective Projection = iota
+	Orthographic
+)
+
+type Camera struct {
+	Position    linalg.Vec3
+	Target      linalg.Vec3
+	Up          linalg.Vec3
+	FOV         float64
+	Near        float64
+	Far         float64
+	OrthoHeight float64
+	Projection  Projection
+}
+
+// NewCamera returns a perspective camera 3 units in front of the origin on
+// the -Z side, looking at it. That is the side the original orthographic
+// view showed, so models keep facing the viewer.
+func NewCamera() *Camera {
+	return &Camera{
+		Position:    linalg.Vec3{X: 0, Y: 0, Z: -3},
+		Target:      linalg.Vec3{X: 0, Y: 0, Z: 0},
+		Up:          linalg.Vec3{X: 0, Y: 1, Z: 0},
+		FOV:         45,
+		Near:        0.1,
+		Far:         100,
+		OrthoHeight: 2,
+		Projection:  Perspective,
+	}
+}
+
+// Forward returns the unit vector pointing from Position to Target.
+func (c *Camera) Forward() linalg.Vec3 {
+	return c.Target.Sub(c.Position).Normalize()
+}
+
+// View returns the world-to-camera matrix. The camera looks down its
+// local -Z axis, as in a right-handed look-at.
+func (c *Camera) View() linalg.Mat4 {
+	return linalg.LookAt(c.Position, c.Target, c.Up)
+}
+
+// ProjectionMatrix returns the camera-to-clip matrix for the given
+// width/height aspect ratio of the viewport.
+func (c *Camera) ProjectionMatrix(aspect float64) linalg.Mat4 {
+	if c.Projection == Orthographic {
+		top := c.OrthoHeight / 2
+		return linalg.Orthographic(top*aspect, top, c.Near, c.Far)
+	}
+	return linalg.Perspective(c.FOV*math.Pi/180.0, aspect, c.Near, c.Far)
+}
+
+// ViewProjection returns ProjectionMatrix(aspect) * View().
+func (c *Camera) ViewProjection(aspect float64) linalg.Mat4 {
+	return c.ProjectionMatrix(aspect).Mul(c.View())
+}
+
+// Depth returns the distance of a world-space point along the view direction.
+func (c *Camera) Depth(vertex linalg.Vec3) float64 {
+	return vertex.Sub(c.Position).Dot(c.Forward())
+}
+
+// InClipRange reports whether the point lies between the near and far planes.
+func (c *Camera) InClipRange(vertex linalg.Vec3) bool {
+	d := c.Depth(vertex)
+	return d >= c.Near && d <= c.Far
+}
+
+// IsFrontFacing reports whether a surface with the given world-space normal
+// at vertex faces the camera.
+func (c *Camera) IsFrontFacing(vertex, normal linalg.Vec3) bool {
+	if c.Projection == Orthographic {
+		return normal.Dot(c.Forward()) < 0
+	}
+	return normal.Dot(c.Position.Sub(vertex)) > 0
+}
+
+// Zoom narrows the field of view (or the orthographic height) by factor;
+// a factor above 1 zooms in.
+func (c *Camera) Zoom(factor float64) {
+	if factor <= 0 {
+		return
+	}
+	c.FOV = math.Max(1, math.Min(179, c.FOV/factor))
+	c.OrthoHeight /= factor
+}
+
+// Dolly moves the camera along its view direction by distance, never
+// passing the target.
+func (c *Camera) Dolly(distance float64) {
+	offset := c.Position.Sub(c.Target)
+	length := offset.Len()
+	if length == 0 {
+		return
+	}
+	c.Position = c.Target.Add(offset.Scale(math.Max(c.Near, length-distance) / length))
+}
+
+// Project transforms a world-space vertex by a view-projection matrix and
+// performs the perspective divide, returning normalized device coordinates.
+func Project(viewProjection linalg.Mat4, vertex linalg.Vec3) linalg.Vec3 {
+	return viewProjection.MulPoint(vertex)
+}
+
+// Viewport maps normalized device coordinates to fractional screen cells,
+// with (0, 0) at the top-left corner of a cols x rows grid.
+func Viewport(x, y float64, cols, rows int) (float64, float64) {
+	return float64(cols)/2.0 + x/2.0*float64(cols), float64(rows)/2.0 + y/-2.0*float64(rows)
+}
@@ -0,0 +1,312 @@
+package canvas
+
+/**
+ * Sub-pixel drawing surface resolved into terminal cells.
+ *
+ * @param width   number of pixels per row
+ * @param height  number of pixel rows
+ * @param pixels  color of every pixel; DefaultColor marks an empty pixel
+ * @param depth   per-pixel depth of the closest fragment drawn so far
+ *
+ * A canvas is a grid of colored pixels several times finer than the cell
+ * grid of a Matrix. Once a frame is drawn, Resolve packs the pixels of each
+ * cell into one block character with a foreground and a background color:
+ * - HalfBlock  1x2 pixels per cell using ▀ and ▄, exact for any two colors
+ * - Quadrant   2x2 pixels per cell using the quadrant blocks; when the four
+ *              pixels have more than two colors they are split into the two
+ *              most different groups and each group is averaged
+ * - Braille    2x4 dots per cell using the braille patterns; every drawn
+ *              pixel raises a dot and the cell takes the average color of
+ *              its dots, which suits wireframes and point clouds
+ */
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"zontengine/internal/matrix"
+)
+
+// Mode selects how many pixels make up a cell and which characters draw them.
+type Mode int
+
+const (
+	// Cells draws one shaded character per cell without a canvas.
+	Cells Mode = iota
+	// HalfBlock splits every cell into an upper and a lower pixel.
+	HalfBlock
+	// Quadrant splits every cell into 2x2 pixels.
+	Quadrant
+	// Braille splits every cell into 2x4 dots.
+	Braille
+)
+
+// CellSize returns the number of pixels per cell horizontally and vertically.
+func (m Mode) CellSize() (int, int) {
+	switch m {
+	case HalfBlock:
+		return 1, 2
+	case Quadrant:
+		return 2, 2
+	case Braille:
+		return 2, 4
+	}
+	return 1, 1
+}
+
+func (m Mode) String() string {
+	switch m {
+	case HalfBlock:
+		return "half"
+	case Quadrant:
+		return "quadrant"
+	case Braille:
+		return "braille"
+	}
+	return "cell"
+}
+
+// ParseMode converts the name of a mode as returned by String.
+func ParseMode(name string) (Mode, error) {
+	for _, mode := range []Mode{Cells, HalfBlock, Quadrant, Braille} {
+		if strings.EqualFold(name, mode.String()) {
+			return mode, nil
+		}
+	}
+	return Cells, fmt.Errorf("unknown pixel mode %q (want cell, half, quadrant or braille)", name)
+}
+
+type Canvas struct {
+	width  int
+	height int
+	pixels []matrix.Color
+	depth  []float64
+}
+
+func NewCanvas(width, height int) *Canvas {
+	c := &Canvas{
+		width:  width,
+		height: height,
+		pixels: make([]matrix.Color, width*height),
+		depth:  make([]float64, width*height),
+	}
+	c.Clear()
+	return c
+}
+
+// ForMatrix returns a canvas covering cols x rows cells in the given mode.
+func ForMatrix(mode Mode, cols, rows int) *Canvas {
+	w, h := mode.CellSize()
+	return NewCanvas(cols*w, rows*h)
+}
+
+func (c *Canvas) Size() (int, int) {
+	return c.width, c.height
+}
+
+// Clear empties every pixel and resets the depth to +Inf.
+func (c *Canvas) Clear() {
+	for i := range c.pixels {
+		c.pixels[i] = matrix.DefaultColor
+		c.depth[i] = math.Inf(1)
+	}
+}
+
+func (c *Canvas) inside(x, y int) bool {
+	return x >= 0 && x < c.width && y >= 0 && y < c.height
+}
+
+// Set colors a pixel; pixels outside the canvas are ignored.
+func (c *Canvas) Set(x, y int, color matrix.Color) {
+	if c.inside(x, y) {
+		c.pixels[y*c.width+x] = color
+	}
+}
+
+// At returns the color of a pixel, DefaultColor if it is empty or outside.
+func (c *Canvas) At(x, y int) matrix.Color {
+	if !c.inside(x, y) {
+		return matrix.DefaultColor
+	}
+	return c.pixels[y*c.width+x]
+}
+
+// DepthTest reports whether z is closer (smaller) than the depth stored for
+// the pixel and, if so, records it.
+func (c *Canvas) DepthTest(x, y int, z float64) bool {
+	if !c.inside(x, y) {
+		return false
+	}
+	i := y*c.width + x
+	if z >= c.depth[i] {
+		return false
+	}
+	c.depth[i] = z
+	return true
+}
+
+// SetDepth records z for a pixel without testing it.
+func (c *Canvas) SetDepth(x, y int, z float64) {
+	if c.inside(x, y) {
+		c.depth[y*c.width+x] = z
+	}
+}
+
+// DepthAt returns the depth recorded for a pixel, +Inf if none.
+func (c *Canvas) DepthAt(x, y int) float64 {
+	if !c.inside(x, y) {
+		return math.Inf(1)
+	}
+	return c.depth[y*c.width+x]
+}
+
+// ResolveDepth writes the closest depth among the pixels of each cell into
+// dst, sized like the dst of Resolve.
+func (c *Canvas) ResolveDepth(mode Mode, dst [][]float64) {
+	w, h := mode.CellSize()
+	for row := 0; row < len(dst) && row*h < c.height; row++ {
+		for col := 0; col < len(dst[row]) && col*w < c.width; col++ {
+			closest := math.Inf(1)
+			for y := row * h; y < (row+1)*h; y++ {
+				for x := col * w; x < (col+1)*w; x++ {
+					closest = math.Min(closest, c.DepthAt(x, y))
+				}
+			}
+			dst[row][col] = closest
+		}
+	}
+}
+
+// Resolve writes the canvas into the cells of dst, which must be at least
+// width/cellWidth x height/cellHeight cells for the mode. A trailing newline
+// column in dst is left untouched.
+func (c *Canvas) Resolve(mode Mode, dst [][]matrix.Cell) {
+	w, h := mode.CellSize()
+	for row := 0; row < len(dst) && row*h < c.height; row++ {
+		for col := 0; col < len(dst[row]) && col*w < c.width; col++ {
+			switch mode {
+			case HalfBlock:
+				dst[row][col] = halfBlock(c.At(col, row*2), c.At(col, row*2+1))
+			case Quadrant:
+				dst[row][col] = quadrant([4]matrix.Color{
+					c.At(col*2, row*2), c.At(col*2+1, row*2),
+					c.At(col*2, row*2+1), c.At(col*2+1, row*2+1),
+				})
+			case Braille:
+				dst[row][col] = c.braille(col*2, row*4)
+			default:
+				if color := c.At(col, row); !color.IsDefault() {
+					dst[row][col] = matrix.Cell{Ch: '█', Fg: color}
+				}
+			}
+		}
+	}
+}
+
+func halfBlock(top, bottom matrix.Color) matrix.Cell {
+	switch {
+	case top.IsDefault() && bottom.IsDefault():
+		return matrix.BlankCell
+	case bottom.IsDefault():
+		return matrix.Cell{Ch: '▀', Fg: top}
+	case top.IsDefault():
+		return matrix.Cell{Ch: '▄', Fg: bottom}
+	case top == bottom:
+		return matrix.Cell{Ch: '█', Fg: top}
+	}
+	return matrix.Cell{Ch: '▀', Fg: top, Bg: bottom}
+}
+
+// quadrantChars is indexed by a mask of the foreground pixels: 1 top-left,
+// 2 top-right, 4 bottom-left, 8 bottom-right.
+var quadrantChars = [16]rune{' ', '▘', '▝', '▀', '▖', '▌', '▞', '▛', '▗', '▚', '▐', '▜', '▄', '▙', '▟', '█'}
+
+// quadrant packs pixels (top-left, top-right, bottom-left, bottom-right)
+// into one cell. Empty pixels become the terminal background; otherwise
+// the pixels are split around the two most different colors.
+func quadrant(pixels [4]matrix.Color) matrix.Cell {
+	var set []matrix.Color
+	mask := 0
+	for i, p := range pixels {
+		if !p.IsDefault() {
+			set = append(set, p)
+			mask |= 1 << i
+		}
+	}
+	if mask == 0 {
+		return matrix.BlankCell
+	}
+	if mask != 15 {
+		return matrix.Cell{Ch: quadrantChars[mask], Fg: average(set)}
+	}
+
+	a, b := pixels[0], pixels[0]
+	farthest := -1
+	for i := range pixels {
+		for j := i + 1; j < len(pixels); j++ {
+			if d := distance(pixels[i], pixels[j]); d > farthest {
+				farthest, a, b = d, pixels[i], pixels[j]
+			}
+		}
+	}
+	if farthest == 0 {
+		return matrix.Cell{Ch: '█', Fg: a}
+	}
+
+	var fg, bg []matrix.Color
+	mask = 0
+	for i, p := range pixels {
+		if distance(p, a) <= distance(p, b) {
+			fg = append(fg, p)
+			mask |= 1 << i
+		} else {
+			bg = append(bg, p)
+		}
+	}
+	return matrix.Cell{Ch: quadrantChars[mask], Fg: average(fg), Bg: average(bg)}
+}
+
+// brailleDots holds the bit of each dot of a braille pattern, indexed by
+// [y][x] within the 2x4 cell.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// braille packs the 2x4 pixels whose top-left pixel is (x, y) into one
+// braille pattern colored with the average of its dots.
+func (c *Canvas) braille(x, y int) matrix.Cell {
+	var dots rune
+	var set []matrix.Color
+	for dy := 0; dy < 4; dy++ {
+		for dx := 0; dx < 2; dx++ {
+			if p := c.At(x+dx, y+dy); !p.IsDefault() {
+				dots |= brailleDots[dy][dx]
+				set = append(set, p)
+			}
+		}
+	}
+	if dots == 0 {
+		return matrix.BlankCell
+	}
+	return matrix.Cell{Ch: 0x2800 + dots, Fg: average(set)}
+}
+
+func distance(a, b matrix.Color) int {
+	ar, ag, ab := a.RGB()
+	br, bg, bb := b.RGB()
+	dr, dg, db := int(ar)-int(br), int(ag)-int(bg), int(ab)-int(bb)
+	return dr*dr + dg*dg + db*db
+}
+
+func average(colors []matrix.Color) matrix.Color {
+	var r, g, b int
+	for _, c := range colors {
+		cr, cg, cb := c.RGB()
+		r, g, b = r+int(cr), g+int(cg), b+int(cb)
+	}
+	n := len(colors)
+	return matrix.RGB(uint8((r+n/2)/n), uint8((g+n/2)/n), uint8((b+n/2)/n))
+}
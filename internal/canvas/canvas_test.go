@@ -0,0 +1,230 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+	"zontengine/internal/matrix"
+)
+
+var (
+	red   = matrix.RGB(255, 0, 0)
+	green = matrix.RGB(0, 255, 0)
+	blue  = matrix.RGB(0, 0, 255)
+	// pink is close enough to red to be grouped with it.
+	pink = matrix.RGB(255, 0, 64)
+)
+
+// pixelColors maps the letters of a pixel layout to colors; '.' is empty.
+var pixelColors = map[byte]matrix.Color{
+	'.': matrix.DefaultColor,
+	'#': matrix.RGB(200, 200, 200),
+	'r': red,
+	'g': green,
+	'b': blue,
+	'p': pink,
+}
+
+// resolveCell draws the layout, one string per pixel row, on the canvas of
+// a single cell and returns the cell it resolves to.
+func resolveCell(t *testing.T, mode Mode, layout []string) matrix.Cell {
+	t.Helper()
+	w, h := mode.CellSize()
+	if len(layout) != h {
+		t.Fatalf("layout has %d rows, want %d", len(layout), h)
+	}
+	c := ForMatrix(mode, 1, 1)
+	for y, line := range layout {
+		if len(line) != w {
+			t.Fatalf("layout row %q has %d pixels, want %d", line, len(line), w)
+		}
+		for x := range line {
+			c.Set(x, y, pixelColors[line[x]])
+		}
+	}
+
+	dst := [][]matrix.Cell{{matrix.BlankCell, matrix.NewlineCell}}
+	c.Resolve(mode, dst)
+	if dst[0][1] != matrix.NewlineCell {
+		t.Fatal("Resolve overwrote the newline column")
+	}
+	return dst[0][0]
+}
+
+func TestResolveHalfBlock(t *testing.T) {
+	tests := []struct {
+		layout []string
+		want   matrix.Cell
+	}{
+		{[]string{".", "."}, matrix.BlankCell},
+		{[]string{"r", "."}, matrix.Cell{Ch: '▀', Fg: red}},
+		{[]string{".", "r"}, matrix.Cell{Ch: '▄', Fg: red}},
+		{[]string{"r", "r"}, matrix.Cell{Ch: '█', Fg: red}},
+		{[]string{"r", "b"}, matrix.Cell{Ch: '▀', Fg: red, Bg: blue}},
+	}
+
+	for _, tt := range tests {
+		if got := resolveCell(t, HalfBlock, tt.layout); got != tt.want {
+			t.Errorf("%q resolves to %q %v/%v, want %q %v/%v", tt.layout, got.Ch, got.Fg, got.Bg, tt.want.Ch, tt.want.Fg, tt.want.Bg)
+		}
+	}
+}
+
+func TestResolveQuadrant(t *testing.T) {
+	// Every combination of drawn pixels picks the glyph showing them.
+	glyphs := []struct {
+		layout []string
+		ch     rune
+	}{
+		{[]string{"..", ".."}, ' '},
+		{[]string{"#.", ".."}, '▘'},
+		{[]string{".#", ".."}, '▝'},
+		{[]string{"##", ".."}, '▀'},
+		{[]string{"..", "#."}, '▖'},
+		{[]string{"#.", "#."}, '▌'},
+		{[]string{".#", "#."}, '▞'},
+		{[]string{"##", "#."}, '▛'},
+		{[]string{"..", ".#"}, '▗'},
+		{[]string{"#.", ".#"}, '▚'},
+		{[]string{".#", ".#"}, '▐'},
+		{[]string{"##", ".#"}, '▜'},
+		{[]string{"..", "##"}, '▄'},
+		{[]string{"#.", "##"}, '▙'},
+		{[]string{".#", "##"}, '▟'},
+		{[]string{"##", "##"}, '█'},
+	}
+	for _, tt := range glyphs {
+		got := resolveCell(t, Quadrant, tt.layout)
+		if got.Ch != tt.ch {
+			t.Errorf("%q resolves to %q, want %q", tt.layout, got.Ch, tt.ch)
+		}
+		if tt.ch != ' ' && (got.Fg != pixelColors['#'] || !got.Bg.IsDefault()) {
+			t.Errorf("%q is colored %v/%v, want %v on the default background", tt.layout, got.Fg, got.Bg, pixelColors['#'])
+		}
+	}
+
+	// Cells with every pixel drawn split into foreground and background.
+	colors := []struct {
+		name   string
+		layout []string
+		want   matrix.Cell
+	}{
+		{"two colors", []string{"rr", "bb"}, matrix.Cell{Ch: '▀', Fg: red, Bg: blue}},
+		{"diagonal", []string{"rb", "br"}, matrix.Cell{Ch: '▚', Fg: red, Bg: blue}},
+		{"one odd pixel", []string{"bb", "rb"}, matrix.Cell{Ch: '▜', Fg: blue, Bg: red}},
+		{"similar colors averaged", []string{"rp", "gg"}, matrix.Cell{Ch: '▀', Fg: matrix.RGB(255, 0, 32), Bg: green}},
+		{"empty pixels averaged", []string{"r.", ".p"}, matrix.Cell{Ch: '▚', Fg: matrix.RGB(255, 0, 32)}},
+	}
+	for _, tt := range colors {
+		if got := resolveCell(t, Quadrant, tt.layout); got != tt.want {
+			t.Errorf("%s: %q resolves to %q %v/%v, want %q %v/%v",
+				tt.name, tt.layout, got.Ch, got.Fg, got.Bg, tt.want.Ch, tt.want.Fg, tt.want.Bg)
+		}
+	}
+}
+
+func TestResolveBraille(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout []string
+		want   matrix.Cell
+	}{
+		{"empty", []string{"..", "..", "..", ".."}, matrix.BlankCell},
+		{"dot 1", []string{"r.", "..", "..", ".."}, matrix.Cell{Ch: '⠁', Fg: red}},
+		{"dot 2", []string{"..", "r.", "..", ".."}, matrix.Cell{Ch: '⠂', Fg: red}},
+		{"dot 3", []string{"..", "..", "r.", ".."}, matrix.Cell{Ch: '⠄', Fg: red}},
+		{"dot 4", []string{".r", "..", "..", ".."}, matrix.Cell{Ch: '⠈', Fg: red}},
+		{"dot 5", []string{"..", ".r", "..", ".."}, matrix.Cell{Ch: '⠐', Fg: red}},
+		{"dot 6", []string{"..", "..", ".r", ".."}, matrix.Cell{Ch: '⠠', Fg: red}},
+		{"dot 7", []string{"..", "..", "..", "r."}, matrix.Cell{Ch: '⡀', Fg: red}},
+		{"dot 8", []string{"..", "..", "..", ".r"}, matrix.Cell{Ch: '⢀', Fg: red}},
+		{"left column", []string{"r.", "r.", "r.", "r."}, matrix.Cell{Ch: '⡇', Fg: red}},
+		{"diagonal", []string{"r.", ".r", "r.", ".r"}, matrix.Cell{Ch: '⢕', Fg: red}},
+		{"all dots", []string{"rr", "rr", "rr", "rr"}, matrix.Cell{Ch: '⣿', Fg: red}},
+		{"colors averaged", []string{"r.", "..", "..", ".b"}, matrix.Cell{Ch: '⢁', Fg: matrix.RGB(128, 0, 128)}},
+	}
+
+	for _, tt := range tests {
+		if got := resolveCell(t, Braille, tt.layout); got != tt.want {
+			t.Errorf("%s: %q resolves to %q %v, want %q %v", tt.name, tt.layout, got.Ch, got.Fg, tt.want.Ch, tt.want.Fg)
+		}
+	}
+}
+
+// TestResolveDepth draws a far and a near fragment overlapping in one
+// pixel and checks that the near one decides both the pixel's color and
+// the cell's depth.
+func TestResolveDepth(t *testing.T) {
+	inf := math.Inf(1)
+	tests := []struct {
+		name  string
+		mode  Mode
+		far   []int // pixels of the far blue fragment, as x, y pairs
+		near  []int // pixels of the near red fragment
+		want  matrix.Cell
+		depth float64
+	}{
+		{"empty", HalfBlock, nil, nil, matrix.BlankCell, inf},
+		{"behind", HalfBlock, []int{0, 0, 0, 1}, []int{0, 1}, matrix.Cell{Ch: '▀', Fg: blue, Bg: red}, 1},
+		{"in front", HalfBlock, []int{0, 1}, []int{0, 0, 0, 1}, matrix.Cell{Ch: '█', Fg: red}, 1},
+		{"far only", Quadrant, []int{1, 1}, nil, matrix.Cell{Ch: '▗', Fg: blue}, 2},
+		{"quadrant split", Quadrant, []int{0, 0, 1, 0, 0, 1, 1, 1}, []int{1, 1}, matrix.Cell{Ch: '▛', Fg: blue, Bg: red}, 1},
+		{"braille", Braille, []int{0, 0, 1, 3}, []int{0, 0}, matrix.Cell{Ch: '⢁', Fg: matrix.RGB(128, 0, 128)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ForMatrix(tt.mode, 1, 1)
+			// Draw near before far, so that only the depth test keeps the
+			// far fragment from covering the near one.
+			for _, fragment := range []struct {
+				pixels []int
+				color  matrix.Color
+				z      float64
+			}{{tt.near, red, 1}, {tt.far, blue, 2}} {
+				for i := 0; i < len(fragment.pixels); i += 2 {
+					x, y := fragment.pixels[i], fragment.pixels[i+1]
+					if c.DepthTest(x, y, fragment.z) {
+						c.Set(x, y, fragment.color)
+					}
+				}
+			}
+
+			cells := [][]matrix.Cell{{matrix.BlankCell}}
+			depth := [][]float64{{-1}}
+			c.Resolve(tt.mode, cells)
+			c.ResolveDepth(tt.mode, depth)
+			if got := cells[0][0]; got != tt.want {
+				t.Errorf("cell is %q %v/%v, want %q %v/%v", got.Ch, got.Fg, got.Bg, tt.want.Ch, tt.want.Fg, tt.want.Bg)
+			}
+			if depth[0][0] != tt.depth {
+				t.Errorf("cell depth is %v, want %v", depth[0][0], tt.depth)
+			}
+		})
+	}
+}
+
+func TestResolveCellsSize(t *testing.T) {
+	// Resolve and ResolveDepth stop at the smaller of the canvas and dst.
+	c := ForMatrix(Quadrant, 2, 1)
+	c.Set(0, 0, red)
+	c.Set(3, 1, blue)
+	c.SetDepth(3, 1, 0.5)
+
+	cells := [][]matrix.Cell{{matrix.BlankCell, matrix.BlankCell, matrix.NewlineCell}, {matrix.BlankCell}}
+	depth := [][]float64{{-1, -1, -1}, {-1}}
+	c.Resolve(Quadrant, cells)
+	c.ResolveDepth(Quadrant, depth)
+
+	want := []matrix.Cell{{Ch: '▘', Fg: red}, {Ch: '▗', Fg: blue}, matrix.NewlineCell}
+	for col, cell := range cells[0] {
+		if cell != want[col] {
+			t.Errorf("cell %d is %q %v, want %q %v", col, cell.Ch, cell.Fg, want[col].Ch, want[col].Fg)
+		}
+	}
+	if cells[1][0] != matrix.BlankCell {
+		t.Errorf("row below the canvas holds %q", cells[1][0].Ch)
+	}
+	if depth[0][0] != math.Inf(1) || depth[0][1] != 0.5 || depth[0][2] != -1 || depth[1][0] != -1 {
+		t.Errorf("depths are %v, want [+Inf 0.5 -1] [-1]", depth)
+	}
+}
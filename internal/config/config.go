@@ -18,6 +18,12 @@ type Config struct {
 	Width     int    `json:"width"`
 	Height    int    `json:"height"`
 	ModelFile string `json:"model_file"`
+	// CellAspect is the width of a terminal cell divided by its height;
+	// 0 detects it from the terminal.
+	CellAspect float64 `json:"cell_aspect,omitempty"`
+	// FitTerminal sizes the image to the terminal and follows it when the
+	// window is resized, ignoring Width and Height.
+	FitTerminal bool `json:"fit_terminal,omitempty"`
 }
 
 func Load() (Config, error) {
@@ -0,0 +1,72 @@
+package export
+
+/**
+ * Recording of terminal output as an asciicast v2 file, the format played by
+ * asciinema and its web player.
+ *
+ * A CastRecorder is an io.Writer: give it to render.SetOutput (or
+ * screen.SetOutput) and every frame DrawScreen writes becomes one output
+ * event stamped with the time since recording started. Line feeds are
+ * written as CR LF, as a terminal driver would, since players do not
+ * translate them.
+ */
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CastHeader is the first line of an asciicast v2 file.
+type CastHeader struct {
+	// Width and Height are the size of the terminal in cells; a frame of
+	// n rows ends with a line feed, so it needs a height of n+1.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Timestamp is when recording started, in Unix seconds; zero means now.
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+type CastRecorder struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	start   time.Time
+}
+
+// NewCastRecorder writes the header to w and starts the clock of the events.
+func NewCastRecorder(w io.Writer, header CastHeader) (*CastRecorder, error) {
+	start := time.Now()
+	if header.Timestamp == 0 {
+		header.Timestamp = start.Unix()
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(struct {
+		Version int `json:"version"`
+		CastHeader
+	}{2, header})
+	if err != nil {
+		return nil, err
+	}
+	return &CastRecorder{encoder: encoder, start: start}, nil
+}
+
+// Write records p as one output event. p should hold whole characters; a
+// UTF-8 sequence split across writes is recorded as invalid.
+func (c *CastRecorder) Write(p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elapsed := math.Round(time.Since(c.start).Seconds()*1e6) / 1e6
+	data := strings.ReplaceAll(string(p), "\n", "\r\n")
+	if err := c.encoder.Encode([]any{elapsed, "o", data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
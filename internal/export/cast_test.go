@@ -0,0 +1,140 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muesli/termenv"
+
+	"zontengine/internal/linalg"
+	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+	"zontengine/internal/render"
+)
+
+type castEvent struct {
+	time float64
+	kind string
+	data string
+}
+
+// parseCast splits an asciicast v2 recording into its header and events,
+// failing the test on anything malformed.
+func parseCast(t *testing.T, data []byte) (map[string]any, []castEvent) {
+	t.Helper()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() {
+		t.Fatal("recording is empty")
+	}
+	var header map[string]any
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatalf("header is not a JSON object: %v: %s", err, scanner.Bytes())
+	}
+
+	var events []castEvent
+	for scanner.Scan() {
+		var fields []any
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			t.Fatalf("event is not a JSON array: %v: %s", err, scanner.Bytes())
+		}
+		if len(fields) != 3 {
+			t.Fatalf("event has %d fields, want 3: %s", len(fields), scanner.Bytes())
+		}
+		var event castEvent
+		var ok [3]bool
+		event.time, ok[0] = fields[0].(float64)
+		event.kind, ok[1] = fields[1].(string)
+		event.data, ok[2] = fields[2].(string)
+		if ok != [3]bool{true, true, true} {
+			t.Fatalf("event is not [time, type, data]: %s", scanner.Bytes())
+		}
+		events = append(events, event)
+	}
+	return header, events
+}
+
+func TestCastRecorder(t *testing.T) {
+	var out bytes.Buffer
+	recorder, err := NewCastRecorder(&out, CastHeader{Width: 20, Height: 6, Title: "a <title> & more"})
+	if err != nil {
+		t.Fatalf("NewCastRecorder: %v", err)
+	}
+	writes := []string{"first\nframe", "\033[Hsecond"}
+	for _, data := range writes {
+		time.Sleep(2 * time.Millisecond)
+		if _, err := recorder.Write([]byte(data)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	header, events := parseCast(t, out.Bytes())
+	want := map[string]any{"version": 2.0, "width": 20.0, "height": 6.0, "title": "a <title> & more"}
+	for key, value := range want {
+		if header[key] != value {
+			t.Errorf("header %s is %v, want %v", key, header[key], value)
+		}
+	}
+	if timestamp, _ := header["timestamp"].(float64); timestamp < float64(time.Now().Add(-time.Minute).Unix()) {
+		t.Errorf("header timestamp is %v, want the start of recording", header["timestamp"])
+	}
+	if strings.Contains(out.String(), `\u003c`) {
+		t.Error("header escapes HTML characters")
+	}
+
+	if len(events) != len(writes) {
+		t.Fatalf("got %d events, want %d", len(events), len(writes))
+	}
+	wantData := []string{"first\r\nframe", "\033[Hsecond"}
+	for i, event := range events {
+		if event.kind != "o" || event.data != wantData[i] {
+			t.Errorf("event %d is %q %q, want \"o\" %q", i, event.kind, event.data, wantData[i])
+		}
+		if event.time <= 0 || i > 0 && event.time <= events[i-1].time {
+			t.Errorf("event %d at %v s does not come after the previous one", i, event.time)
+		}
+	}
+}
+
+func TestCastRecorderRender(t *testing.T) {
+	model := &mesh.Mesh{
+		Name:      "triangle",
+		Positions: []linalg.Vec3{{X: -1, Y: -1}, {X: -1, Y: 1}, {X: 1, Y: 1}},
+		Faces: []mesh.Face{{
+			Positions: [3]int{0, 1, 2},
+			Normals:   [3]int{-1, -1, -1},
+			UVs:       [3]int{-1, -1, -1},
+			Material:  -1,
+		}},
+	}
+
+	var out bytes.Buffer
+	recorder, err := NewCastRecorder(&out, CastHeader{Width: 16, Height: 9})
+	if err != nil {
+		t.Fatalf("NewCastRecorder: %v", err)
+	}
+	r := render.NewRender(matrix.NewMatrix(16, 8))
+	r.SetOutput(recorder)
+	r.SetColorProfile(termenv.Ascii)
+	if err := r.Render(context.Background(), model, render.Options{FPS: 200, Frames: 3}); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	// Every drawn frame is one event.
+	_, events := parseCast(t, out.Bytes())
+	if len(events) != 3 {
+		t.Fatalf("got %d events for 3 frames", len(events))
+	}
+	for i, event := range events {
+		if i > 0 && event.time < events[i-1].time {
+			t.Errorf("event %d at %v s comes before the previous one", i, event.time)
+		}
+		if strings.Contains(strings.ReplaceAll(event.data, "\r\n", ""), "\n") {
+			t.Errorf("event %d holds a bare line feed", i)
+		}
+	}
+}
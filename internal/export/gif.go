@@ -0,0 +1,145 @@
+package export
+
+/**
+ * Animated GIF export of a sequence of frames, e.g. a turntable from
+ * render.RenderTurntable.
+ *
+ * Every frame is drawn like an image (see ImageOptions) and mapped onto one
+ * palette shared by the whole animation. Without a palette of its own the
+ * animation uses exactly the colors it contains when there are at most 256
+ * of them, which holds for monochrome and most ASCII renders, and the Plan 9
+ * palette otherwise.
+ */
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"strings"
+	"time"
+	"zontengine/internal/render"
+)
+
+// DefaultGIFDelay is how long each frame is shown when GIFOptions.Delay is 0.
+const DefaultGIFDelay = 50 * time.Millisecond
+
+type GIFOptions struct {
+	ImageOptions
+	// Delay is how long each frame is shown, rounded to the hundredths of a
+	// second GIF stores.
+	Delay time.Duration
+	// Palette holds the colors of the animation, at most 256; nil picks
+	// them from the frames.
+	Palette color.Palette
+	// Dither spreads the error of colors missing from the palette over
+	// neighbouring pixels instead of using the nearest color.
+	Dither bool
+}
+
+// delay returns the frame delay in hundredths of a second, at least one.
+func (o GIFOptions) delay() int {
+	delay := o.Delay
+	if delay <= 0 {
+		delay = DefaultGIFDelay
+	}
+	return max(1, int((delay+5*time.Millisecond)/(10*time.Millisecond)))
+}
+
+// ParsePalette converts "auto", "plan9", "websafe" or "gray" to a palette;
+// "auto" returns nil, which picks the colors from the frames.
+func ParsePalette(name string) (color.Palette, error) {
+	switch strings.ToLower(name) {
+	case "auto", "":
+		return nil, nil
+	case "plan9":
+		return palette.Plan9, nil
+	case "websafe":
+		return palette.WebSafe, nil
+	case "gray":
+		gray := make(color.Palette, 256)
+		for i := range gray {
+			gray[i] = color.Gray{Y: uint8(i)}
+		}
+		return gray, nil
+	}
+	return nil, fmt.Errorf("unknown palette %q (want auto, plan9, websafe or gray)", name)
+}
+
+// GIF encodes the frames as an animation that loops forever.
+func GIF(frames []*render.Frame, opts GIFOptions) (*gif.GIF, error) {
+	if len(frames) == 0 {
+		return nil, errors.New("export: no frames to animate")
+	}
+
+	images := make([]*image.RGBA, len(frames))
+	for i, frame := range frames {
+		images[i] = Image(frame, opts.ImageOptions)
+	}
+
+	colors := opts.Palette
+	if colors == nil {
+		colors = framePalette(images)
+	}
+	if len(colors) == 0 || len(colors) > 256 {
+		return nil, fmt.Errorf("export: palette has %d colors (want 1 to 256)", len(colors))
+	}
+
+	var drawer draw.Drawer = draw.Src
+	if opts.Dither {
+		drawer = draw.FloydSteinberg
+	}
+
+	animation := &gif.GIF{
+		Image: make([]*image.Paletted, len(images)),
+		Delay: make([]int, len(images)),
+	}
+	for i, img := range images {
+		paletted := image.NewPaletted(img.Bounds(), colors)
+		drawer.Draw(paletted, img.Bounds(), img, image.Point{})
+		animation.Image[i] = paletted
+		animation.Delay[i] = opts.delay()
+	}
+	return animation, nil
+}
+
+// WriteGIF encodes the frames as an animated GIF.
+func WriteGIF(w io.Writer, frames []*render.Frame, opts GIFOptions) error {
+	animation, err := GIF(frames, opts)
+	if err != nil {
+		return err
+	}
+	return gif.EncodeAll(w, animation)
+}
+
+// SaveGIF writes the frames to an animated GIF file.
+func SaveGIF(filename string, frames []*render.Frame, opts GIFOptions) error {
+	return saveFile(filename, func(w io.Writer) error {
+		return WriteGIF(w, frames, opts)
+	})
+}
+
+// framePalette returns the colors used by the images when there are at most
+// 256 of them, and the Plan 9 palette otherwise.
+func framePalette(images []*image.RGBA) color.Palette {
+	seen := make(map[color.RGBA]bool)
+	var colors color.Palette
+	for _, img := range images {
+		for i := 0; i < len(img.Pix); i += 4 {
+			c := color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}
+			if seen[c] {
+				continue
+			}
+			if len(colors) == 256 {
+				return palette.Plan9
+			}
+			seen[c] = true
+			colors = append(colors, c)
+		}
+	}
+	return colors
+}
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"bytes"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"testing"
+	"time"
+
+	"zontengine/internal/matrix"
+	"zontengine/internal/render"
+)
+
+// turntableFrames returns count frames, each with a red cell in another
+// column.
+func turntableFrames(count int) []*render.Frame {
+	frames := make([]*render.Frame, count)
+	for i := range frames {
+		frames[i] = render.NewFrame(count, 1)
+		frames[i].Cells[0][i] = matrix.Cell{Ch: '█', Fg: matrix.RGB(255, 0, 0)}
+	}
+	return frames
+}
+
+func TestWriteGIF(t *testing.T) {
+	gray, err := ParsePalette("gray")
+	if err != nil {
+		t.Fatalf("ParsePalette: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		opts  GIFOptions
+		delay int
+		// colors is the palette, picked in the order the colors first
+		// appear unless given.
+		colors []color.Color
+	}{
+		{
+			name:   "defaults",
+			delay:  5,
+			colors: []color.Color{color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0, 0xff}},
+		},
+		{
+			name:   "monochrome",
+			opts:   GIFOptions{ImageOptions: ImageOptions{Monochrome: true}, Delay: 120 * time.Millisecond},
+			delay:  12,
+			colors: []color.Color{color.RGBA{0xcc, 0xcc, 0xcc, 0xff}, color.RGBA{0, 0, 0, 0xff}},
+		},
+		{
+			name:   "short delay",
+			opts:   GIFOptions{Delay: time.Millisecond},
+			delay:  1,
+			colors: []color.Color{color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0, 0xff}},
+		},
+		{
+			name:   "fixed palette",
+			opts:   GIFOptions{Palette: gray},
+			delay:  5,
+			colors: gray,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := turntableFrames(3)
+			var out bytes.Buffer
+			if err := WriteGIF(&out, frames, tt.opts); err != nil {
+				t.Fatalf("WriteGIF: %v", err)
+			}
+			animation, err := gif.DecodeAll(&out)
+			if err != nil {
+				t.Fatalf("output is not a GIF: %v", err)
+			}
+
+			if len(animation.Image) != len(frames) || len(animation.Delay) != len(frames) {
+				t.Fatalf("got %d images and %d delays, want %d", len(animation.Image), len(animation.Delay), len(frames))
+			}
+			if animation.LoopCount != 0 {
+				t.Errorf("loop count is %d, want 0 (forever)", animation.LoopCount)
+			}
+			width, height := 3*fontWidth, fontHeight
+			for i, img := range animation.Image {
+				if animation.Delay[i] != tt.delay {
+					t.Errorf("frame %d shows for %d/100 s, want %d", i, animation.Delay[i], tt.delay)
+				}
+				if size := img.Bounds().Size(); size.X != width || size.Y != height {
+					t.Errorf("frame %d is %v, want %dx%d", i, size, width, height)
+				}
+
+				// The encoder pads the palette to a power of two; the
+				// colors chosen come first.
+				if len(img.Palette) < len(tt.colors) {
+					t.Fatalf("frame %d has %d colors, want %d", i, len(img.Palette), len(tt.colors))
+				}
+				for c, want := range tt.colors {
+					if !sameColor(img.Palette[c], want) {
+						t.Errorf("frame %d color %d is %v, want %v", i, c, img.Palette[c], want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGIFErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames []*render.Frame
+		opts   GIFOptions
+	}{
+		{name: "no frames"},
+		{name: "palette too large", frames: turntableFrames(1), opts: GIFOptions{Palette: append(color.Palette{color.White}, palette.Plan9...)}},
+		{name: "empty palette", frames: turntableFrames(1), opts: GIFOptions{Palette: color.Palette{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GIF(tt.frames, tt.opts); err == nil {
+				t.Error("GIF succeeded, want an error")
+			}
+		})
+	}
+}
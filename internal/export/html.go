@@ -0,0 +1,207 @@
+package export
+
+/**
+ * Standalone HTML export of frames as colored text.
+ *
+ * The page needs nothing but a browser: one frame becomes a <pre> element,
+ * several become an animation whose frames are embedded in a small script
+ * that swaps them in and out; clicking the picture pauses and resumes it.
+ * Every pair of cell colors is turned into a CSS class shared by all
+ * frames, and neighbouring cells with the same colors into one span.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"strings"
+	"time"
+	"zontengine/internal/matrix"
+	"zontengine/internal/render"
+)
+
+// HTMLCellAspect is the width of a cell divided by its height on the page:
+// monospace fonts are about 0.6em wide and lines are 1.2em apart.
+const HTMLCellAspect = 0.5
+
+type HTMLOptions struct {
+	Title string
+	// Foreground colors cells without a color of their own, and every cell
+	// when Monochrome is set; nil means light gray.
+	Foreground color.Color
+	// Background is the color of the page and of cells without one; nil
+	// means black.
+	Background color.Color
+	Monochrome bool
+	// Delay is how long each frame of an animation is shown; zero means
+	// DefaultGIFDelay.
+	Delay time.Duration
+}
+
+func (o HTMLOptions) delay() time.Duration {
+	if o.Delay <= 0 {
+		return DefaultGIFDelay
+	}
+	return o.Delay
+}
+
+// htmlPage is filled with the title, the page colors, the color classes,
+// the first frame and, for animations, the player script.
+const htmlPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { margin: 0; background: %s; }
+pre { margin: 1em; color: %s; font: 14px/1.2 ui-monospace, Menlo, Consolas, "DejaVu Sans Mono", monospace; }
+%s</style>
+</head>
+<body>
+<pre id="frame">%s</pre>
+%s</body>
+</html>
+`
+
+// htmlPlayer is filled with the frames as a JSON array and the delay in
+// milliseconds.
+const htmlPlayer = `<script>
+(function () {
+  var frames = %s, delay = %d, index = 0, timer = null;
+  var pre = document.getElementById("frame");
+  function step() {
+    index = (index + 1) %% frames.length;
+    pre.innerHTML = frames[index];
+  }
+  function toggle() {
+    if (timer) {
+      clearInterval(timer);
+      timer = null;
+    } else {
+      timer = setInterval(step, delay);
+    }
+  }
+  pre.onclick = toggle;
+  toggle();
+})();
+</script>
+`
+
+// WriteHTML writes the frames as an HTML page: a still picture for one
+// frame, a looping animation for more.
+func WriteHTML(w io.Writer, frames []*render.Frame, opts HTMLOptions) error {
+	if len(frames) == 0 {
+		return errors.New("export: no frames to write")
+	}
+
+	classes := newColorClasses(opts.Monochrome)
+	markup := make([]string, len(frames))
+	for i, frame := range frames {
+		markup[i] = classes.frameHTML(frame)
+	}
+
+	player := ""
+	if len(frames) > 1 {
+		encoded, err := json.Marshal(markup)
+		if err != nil {
+			return err
+		}
+		player = fmt.Sprintf(htmlPlayer, encoded, opts.delay().Milliseconds())
+	}
+
+	title := opts.Title
+	if title == "" {
+		title = "zont"
+	}
+	fg, bg := cssColor(opts.Foreground, "#cccccc"), cssColor(opts.Background, "#000000")
+	_, err := fmt.Fprintf(w, htmlPage, html.EscapeString(title), bg, fg, classes.css(), markup[0], player)
+	return err
+}
+
+// SaveHTML writes the frames to an HTML file.
+func SaveHTML(filename string, frames []*render.Frame, opts HTMLOptions) error {
+	return saveFile(filename, func(w io.Writer) error {
+		return WriteHTML(w, frames, opts)
+	})
+}
+
+func cssColor(c color.Color, fallback string) string {
+	if c == nil {
+		return fallback
+	}
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// colorClasses names every foreground/background pair in order of first use.
+type colorClasses struct {
+	monochrome bool
+	names      map[[2]matrix.Color]string
+	order      [][2]matrix.Color
+}
+
+func newColorClasses(monochrome bool) *colorClasses {
+	return &colorClasses{monochrome: monochrome, names: make(map[[2]matrix.Color]string)}
+}
+
+// class returns the class of a cell, "" when it has the page colors.
+func (c *colorClasses) class(cell matrix.Cell) string {
+	colors := [2]matrix.Color{cell.Fg, cell.Bg}
+	if c.monochrome || colors == ([2]matrix.Color{}) {
+		return ""
+	}
+	if name, exists := c.names[colors]; exists {
+		return name
+	}
+	name := fmt.Sprintf("c%d", len(c.order))
+	c.names[colors] = name
+	c.order = append(c.order, colors)
+	return name
+}
+
+func (c *colorClasses) css() string {
+	var css strings.Builder
+	for _, colors := range c.order {
+		fmt.Fprintf(&css, ".%s {", c.names[colors])
+		if !colors[0].IsDefault() {
+			fmt.Fprintf(&css, " color: %s;", colors[0].Hex())
+		}
+		if !colors[1].IsDefault() {
+			fmt.Fprintf(&css, " background: %s;", colors[1].Hex())
+		}
+		css.WriteString(" }\n")
+	}
+	return css.String()
+}
+
+// frameHTML returns the content of the <pre> element showing frame.
+func (c *colorClasses) frameHTML(frame *render.Frame) string {
+	var markup strings.Builder
+	for row, cells := range frame.Cells {
+		if row > 0 {
+			markup.WriteByte('\n')
+		}
+		for col := 0; col < len(cells); {
+			class := c.class(cells[col])
+			end := col + 1
+			for end < len(cells) && c.class(cells[end]) == class {
+				end++
+			}
+
+			var text strings.Builder
+			for _, cell := range cells[col:end] {
+				text.WriteRune(cell.Ch)
+			}
+			if class == "" {
+				markup.WriteString(html.EscapeString(text.String()))
+			} else {
+				fmt.Fprintf(&markup, `<span class="%s">%s</span>`, class, html.EscapeString(text.String()))
+			}
+			col = end
+		}
+	}
+	return markup.String()
+}
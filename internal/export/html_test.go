@@ -0,0 +1,113 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"zontengine/internal/matrix"
+	"zontengine/internal/render"
+)
+
+// markupFrame returns a frame whose text needs escaping: markup characters
+// and a closing script tag, in and out of colored cells.
+func markupFrame(first string) *render.Frame {
+	lines := []string{first, "</script>"}
+	frame := render.NewFrame(9, len(lines))
+	for row, line := range lines {
+		for col, ch := range line {
+			frame.Cells[row][col] = matrix.Cell{Ch: ch}
+		}
+	}
+	frame.Cells[0][1].Fg = matrix.RGB(255, 0, 0)
+	frame.Cells[0][2].Fg = matrix.RGB(255, 0, 0)
+	frame.Cells[1][0].Bg = matrix.RGB(0, 0, 255)
+	return frame
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// frameText returns the text shown by the markup of a frame. The tags can be
+// stripped blindly because escaped text holds no '<'.
+func frameText(markup string) string {
+	return html.UnescapeString(htmlTag.ReplaceAllString(markup, ""))
+}
+
+func between(t *testing.T, s, start, end string) string {
+	t.Helper()
+	i := strings.Index(s, start)
+	if i < 0 {
+		t.Fatalf("output has no %q:\n%s", start, s)
+	}
+	s = s[i+len(start):]
+	j := strings.Index(s, end)
+	if j < 0 {
+		t.Fatalf("output has no %q after %q:\n%s", end, start, s)
+	}
+	return s[:j]
+}
+
+func TestWriteHTMLFrame(t *testing.T) {
+	frame := markupFrame(`<&>"'`)
+	var out bytes.Buffer
+	if err := WriteHTML(&out, []*render.Frame{frame}, HTMLOptions{Title: "Tom & <Jerry>"}); err != nil {
+		t.Fatalf("WriteHTML: %v", err)
+	}
+	page := out.String()
+
+	if title := between(t, page, "<title>", "</title>"); title != "Tom &amp; &lt;Jerry&gt;" {
+		t.Errorf("title is %q, want it escaped", title)
+	}
+	markup := between(t, page, `<pre id="frame">`, "</pre>")
+	if got := frameText(markup); got != frame.String() {
+		t.Errorf("page shows\n%q\nwant\n%q", got, frame.String())
+	}
+	if !strings.Contains(markup, `<span class="c0">&amp;&gt;</span>`) {
+		t.Errorf("colored cells are not one escaped span:\n%s", markup)
+	}
+	if !strings.Contains(page, ".c0 { color: #ff0000; }") || !strings.Contains(page, ".c1 { background: #0000ff; }") {
+		t.Errorf("color classes missing:\n%s", page)
+	}
+	if strings.Contains(page, "<script>") {
+		t.Error("a single frame has a player")
+	}
+}
+
+func TestWriteHTMLAnimation(t *testing.T) {
+	frames := []*render.Frame{markupFrame("<b>one"), markupFrame("&amp; two")}
+	var out bytes.Buffer
+	if err := WriteHTML(&out, frames, HTMLOptions{Delay: 80 * time.Millisecond}); err != nil {
+		t.Fatalf("WriteHTML: %v", err)
+	}
+	page := out.String()
+
+	if count := strings.Count(page, "</script>"); count != 1 {
+		t.Fatalf("page has %d closing script tags, want 1", count)
+	}
+
+	var markup []string
+	if err := json.Unmarshal([]byte(between(t, page, "var frames = ", ", delay = ")), &markup); err != nil {
+		t.Fatalf("frames are not a JSON array of strings: %v", err)
+	}
+	if len(markup) != len(frames) {
+		t.Fatalf("player has %d frames, want %d", len(markup), len(frames))
+	}
+	for i := range frames {
+		if got := frameText(markup[i]); got != frames[i].String() {
+			t.Errorf("frame %d shows\n%q\nwant\n%q", i, got, frames[i].String())
+		}
+	}
+	if delay := between(t, page, ", delay = ", ","); delay != "80" {
+		t.Errorf("delay is %s ms, want 80", delay)
+	}
+}
+
+func TestWriteHTMLNoFrames(t *testing.T) {
+	if err := WriteHTML(&bytes.Buffer{}, nil, HTMLOptions{}); err == nil {
+		t.Error("WriteHTML succeeded without frames")
+	}
+}
@@ -0,0 +1,241 @@
+package export
+
+/**
+ * Converts rendered frames into images and other file formats.
+ *
+ * Images come in two modes:
+ * - Glyphs  every cell is drawn as its character in a bundled 7x13 bitmap
+ *           font, so the image looks like the terminal
+ * - Pixels  every cell becomes a 2x4 block of pixels showing its color, with
+ *           half-block, quadrant and braille cells split into their parts,
+ *           which recovers the shaded pixel grid behind the characters
+ *
+ * Block elements and braille patterns are drawn geometrically in both modes
+ * rather than taken from the font.
+ */
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"zontengine/internal/matrix"
+	"zontengine/internal/render"
+)
+
+// ImageMode selects how cells are turned into pixels.
+type ImageMode int
+
+const (
+	// Glyphs draws the characters of the frame.
+	Glyphs ImageMode = iota
+	// Pixels draws the colors of the frame as a pixel grid.
+	Pixels
+)
+
+func (m ImageMode) String() string {
+	if m == Pixels {
+		return "pixels"
+	}
+	return "glyphs"
+}
+
+// ParseImageMode converts the name of a mode as returned by String.
+func ParseImageMode(name string) (ImageMode, error) {
+	for _, mode := range []ImageMode{Glyphs, Pixels} {
+		if strings.EqualFold(name, mode.String()) {
+			return mode, nil
+		}
+	}
+	return Glyphs, fmt.Errorf("unknown image content %q (want glyphs or pixels)", name)
+}
+
+// pixelCellWidth and pixelCellHeight are the size of a cell in Pixels mode:
+// fine enough for braille and about the shape of a terminal cell.
+const (
+	pixelCellWidth  = 2
+	pixelCellHeight = 4
+)
+
+type ImageOptions struct {
+	Mode ImageMode
+	// Scale enlarges every pixel to Scale x Scale; zero means 1.
+	Scale int
+	// Foreground colors cells without a color of their own, and every cell
+	// when Monochrome is set; nil means light gray.
+	Foreground color.Color
+	// Background fills cells without a background color; nil means black.
+	Background color.Color
+	Monochrome bool
+}
+
+func (o ImageOptions) cellSize() (int, int) {
+	scale := o.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	if o.Mode == Pixels {
+		return pixelCellWidth * scale, pixelCellHeight * scale
+	}
+	return fontWidth * scale, fontHeight * scale
+}
+
+// CellAspect returns the width of a cell divided by its height in the
+// image, for render.SetCellAspect.
+func (o ImageOptions) CellAspect() float64 {
+	w, h := o.cellSize()
+	return float64(w) / float64(h)
+}
+
+func (o ImageOptions) foreground() color.Color {
+	if o.Foreground == nil {
+		return color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	}
+	return o.Foreground
+}
+
+func (o ImageOptions) background() color.Color {
+	if o.Background == nil {
+		return color.Black
+	}
+	return o.Background
+}
+
+// Image draws the frame into a new image.
+func Image(frame *render.Frame, opts ImageOptions) *image.RGBA {
+	cellWidth, cellHeight := opts.cellSize()
+	img := image.NewRGBA(image.Rect(0, 0, frame.Cols*cellWidth, frame.Rows*cellHeight))
+
+	for row, cells := range frame.Cells {
+		for col, cell := range cells {
+			bounds := image.Rect(col*cellWidth, row*cellHeight, (col+1)*cellWidth, (row+1)*cellHeight)
+			drawCell(img, bounds, cell, opts)
+		}
+	}
+	return img
+}
+
+// WritePNG encodes the frame as a PNG image.
+func WritePNG(w io.Writer, frame *render.Frame, opts ImageOptions) error {
+	return png.Encode(w, Image(frame, opts))
+}
+
+// SavePNG writes the frame to a PNG file.
+func SavePNG(filename string, frame *render.Frame, opts ImageOptions) error {
+	return saveFile(filename, func(w io.Writer) error {
+		return WritePNG(w, frame, opts)
+	})
+}
+
+// saveFile creates filename and fills it with write, reporting the first
+// error from writing, flushing or closing.
+func saveFile(filename string, write func(io.Writer) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(file)
+	if err := write(buffered); err != nil {
+		file.Close()
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// cellColors returns the colors a cell is drawn with.
+func cellColors(cell matrix.Cell, opts ImageOptions) (fg, bg color.Color) {
+	fg, bg = opts.foreground(), opts.background()
+	if opts.Monochrome {
+		return fg, bg
+	}
+	if !cell.Fg.IsDefault() {
+		fg = toRGBA(cell.Fg)
+	}
+	if !cell.Bg.IsDefault() {
+		bg = toRGBA(cell.Bg)
+	}
+	return fg, bg
+}
+
+func toRGBA(c matrix.Color) color.RGBA {
+	r, g, b := c.RGB()
+	return color.RGBA{r, g, b, 0xff}
+}
+
+func drawCell(img *image.RGBA, bounds image.Rectangle, cell matrix.Cell, opts ImageOptions) {
+	fg, bg := cellColors(cell, opts)
+	covered := glyphMask(cell.Ch, bounds.Dx(), bounds.Dy(), opts.Mode)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if covered(x-bounds.Min.X, y-bounds.Min.Y) {
+				img.Set(x, y, fg)
+			} else {
+				img.Set(x, y, bg)
+			}
+		}
+	}
+}
+
+// quadrantMasks gives the parts (1 top-left, 2 top-right, 4 bottom-left,
+// 8 bottom-right) of every block character that is made of quadrants.
+var quadrantMasks = map[rune]int{
+	'▘': 1, '▝': 2, '▀': 3, '▖': 4, '▌': 5, '▞': 6, '▛': 7,
+	'▗': 8, '▚': 9, '▐': 10, '▜': 11, '▄': 12, '▙': 13, '▟': 14, '█': 15,
+}
+
+// glyphMask returns which pixels of a w x h cell showing ch are in the
+// foreground color.
+func glyphMask(ch rune, w, h int, mode ImageMode) func(x, y int) bool {
+	if mask, exists := quadrantMasks[ch]; exists {
+		return func(x, y int) bool {
+			bit := 0
+			if x*2 >= w {
+				bit++
+			}
+			if y*2 >= h {
+				bit += 2
+			}
+			return mask&(1<<bit) != 0
+		}
+	}
+
+	if ch >= 0x2800 && ch <= 0x28ff {
+		dots := int(ch - 0x2800)
+		return func(x, y int) bool {
+			col, row := x*2/w, y*4/h
+			// In glyph mode leave a gap around every dot.
+			if mode == Glyphs && (x*2%w*3 < w || y*4%h*3 < h) {
+				return false
+			}
+			bit := [4][2]int{{0, 3}, {1, 4}, {2, 5}, {6, 7}}[row][col]
+			return dots&(1<<bit) != 0
+		}
+	}
+
+	if ch == ' ' || ch == '\n' {
+		return func(x, y int) bool { return false }
+	}
+
+	// Any other character is a shade of its cell: solid in Pixels mode,
+	// drawn from the font in Glyphs mode.
+	if mode == Pixels {
+		return func(x, y int) bool { return true }
+	}
+	if ch < ' ' || ch > '~' {
+		ch = '?'
+	}
+	glyph := font[ch-' ']
+	return func(x, y int) bool {
+		fx, fy := x*fontWidth/w, y*fontHeight/h
+		return glyph[fy]&(1<<(fontWidth-1-fx)) != 0
+	}
+}
@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"zontengine/internal/matrix"
+	"zontengine/internal/render"
+)
+
+// testFrame returns a 4x2 frame holding a letter, a full block, a quadrant
+// and a braille dot on a row of blanks.
+func testFrame() *render.Frame {
+	frame := render.NewFrame(4, 2)
+	frame.Cells[0][0] = matrix.Cell{Ch: 'A', Fg: matrix.RGB(255, 0, 0)}
+	frame.Cells[0][1] = matrix.Cell{Ch: '█'}
+	frame.Cells[0][2] = matrix.Cell{Ch: '▘', Fg: matrix.RGB(0, 255, 0), Bg: matrix.RGB(0, 0, 255)}
+	frame.Cells[0][3] = matrix.Cell{Ch: '⠁'}
+	return frame
+}
+
+func decodePNG(t *testing.T, frame *render.Frame, opts ImageOptions) image.Image {
+	t.Helper()
+	var out bytes.Buffer
+	if err := WritePNG(&out, frame, opts); err != nil {
+		t.Fatalf("WritePNG: %v", err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatalf("output is not a PNG: %v", err)
+	}
+	return img
+}
+
+func sameColor(a, b color.Color) bool {
+	r1, g1, b1, a1 := a.RGBA()
+	r2, g2, b2, a2 := b.RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+}
+
+func TestWritePNGGlyphs(t *testing.T) {
+	gray := color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	red := color.RGBA{0xff, 0, 0, 0xff}
+
+	for _, scale := range []int{1, 2} {
+		img := decodePNG(t, testFrame(), ImageOptions{Scale: scale})
+		if size := img.Bounds().Size(); size != image.Pt(4*fontWidth*scale, 2*fontHeight*scale) {
+			t.Fatalf("scale %d: image is %v, want %dx%d", scale, size, 4*fontWidth*scale, 2*fontHeight*scale)
+		}
+
+		// 'A' is drawn from the font in its color on the background.
+		glyph := font['A'-' ']
+		for y := 0; y < fontHeight*scale; y++ {
+			for x := 0; x < fontWidth*scale; x++ {
+				want := color.Color(color.Black)
+				if glyph[y/scale]&(1<<(fontWidth-1-x/scale)) != 0 {
+					want = red
+				}
+				if got := img.At(x, y); !sameColor(got, want) {
+					t.Fatalf("scale %d: pixel (%d, %d) of 'A' is %v, want %v", scale, x, y, got, want)
+				}
+			}
+		}
+
+		// The full block fills its cell with the default foreground and a
+		// blank leaves the background.
+		cellWidth, cellHeight := fontWidth*scale, fontHeight*scale
+		for y := 0; y < cellHeight; y++ {
+			for x := 0; x < cellWidth; x++ {
+				if got := img.At(cellWidth+x, y); !sameColor(got, gray) {
+					t.Fatalf("scale %d: pixel (%d, %d) of the block is %v", scale, x, y, got)
+				}
+				if got := img.At(x, cellHeight+y); !sameColor(got, color.Black) {
+					t.Fatalf("scale %d: pixel (%d, %d) of a blank is %v", scale, x, y, got)
+				}
+			}
+		}
+	}
+}
+
+func TestWritePNGPixels(t *testing.T) {
+	gray := color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	green := color.RGBA{0, 0xff, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+
+	img := decodePNG(t, testFrame(), ImageOptions{Mode: Pixels})
+	if size := img.Bounds().Size(); size != image.Pt(4*pixelCellWidth, 2*pixelCellHeight) {
+		t.Fatalf("image is %v, want %dx%d", size, 4*pixelCellWidth, 2*pixelCellHeight)
+	}
+
+	tests := []struct {
+		name string
+		col  int
+		// want lists the pixels of the cell row by row.
+		want [pixelCellHeight][pixelCellWidth]color.Color
+	}{
+		{"letter", 0, [4][2]color.Color{
+			{red, red},
+			{red, red},
+			{red, red},
+			{red, red},
+		}},
+		{"top-left quadrant", 2, [4][2]color.Color{
+			{green, blue},
+			{green, blue},
+			{blue, blue},
+			{blue, blue},
+		}},
+		{"braille dot 1", 3, [4][2]color.Color{
+			{gray, color.Black},
+			{color.Black, color.Black},
+			{color.Black, color.Black},
+			{color.Black, color.Black},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for y, row := range tt.want {
+				for x, want := range row {
+					if got := img.At(tt.col*pixelCellWidth+x, y); !sameColor(got, want) {
+						t.Errorf("pixel (%d, %d) is %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
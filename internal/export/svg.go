@@ -0,0 +1,155 @@
+package export
+
+/**
+ * SVG export of a render, either as vector polygons or as a grid of text.
+ *
+ * Polygons mode draws the triangles from render.RenderPolygons, filled with
+ * their flat lit colors or, with Outline, as a wireframe. Text mode draws
+ * the characters of a frame in a monospaced grid, every run of cells with
+ * the same colors as one <text> element stretched to its cells so the grid
+ * holds whatever font the viewer picks.
+ *
+ * Coordinates are written with two decimals and elements in a fixed order,
+ * so the same render always gives the same file and geometry changes show
+ * up in a plain diff.
+ */
+
+import (
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"strings"
+	"zontengine/internal/matrix"
+	"zontengine/internal/render"
+)
+
+// SVG cells are SVGCellWidth x SVGCellHeight user units, so renders for SVG
+// should use a cell aspect of SVGCellAspect.
+const (
+	SVGCellWidth  = 8
+	SVGCellHeight = 16
+	SVGCellAspect = float64(SVGCellWidth) / SVGCellHeight
+)
+
+type SVGOptions struct {
+	// Outline strokes the polygons in their colors and fills them with the
+	// background, drawing a wireframe with hidden lines removed.
+	Outline bool
+	// Foreground colors outlines and cells without a color of their own,
+	// and everything when Monochrome is set; nil means light gray.
+	Foreground color.Color
+	// Background fills the picture; nil means black.
+	Background color.Color
+	Monochrome bool
+}
+
+func (o SVGOptions) colors() (fg, bg string) {
+	return cssColor(o.Foreground, "#cccccc"), cssColor(o.Background, "#000000")
+}
+
+// WriteSVGPolygons writes polygons in a picture of cols x rows cells.
+func WriteSVGPolygons(w io.Writer, cols, rows int, polygons []render.Polygon, opts SVGOptions) error {
+	var svg strings.Builder
+	fg, bg := opts.colors()
+	writeSVGStart(&svg, cols, rows, bg)
+
+	for _, polygon := range polygons {
+		color := fg
+		if !opts.Monochrome {
+			color = polygon.Color.Hex()
+		}
+
+		points := make([]string, len(polygon.Points))
+		for i, point := range polygon.Points {
+			points[i] = fmt.Sprintf("%.2f,%.2f", point.X*SVGCellWidth, point.Y*SVGCellHeight)
+		}
+
+		fmt.Fprintf(&svg, `<polygon points="%s"`, strings.Join(points, " "))
+		if opts.Outline {
+			// Filling with the background hides the edges behind nearer
+			// faces, as the depth test does on the terminal.
+			fmt.Fprintf(&svg, ` fill="%s" stroke="%s"`, bg, color)
+		} else {
+			// A stroke in the fill color closes the hairline seams that
+			// antialiasing leaves between neighbouring polygons.
+			fmt.Fprintf(&svg, ` fill="%s" stroke="%s" stroke-width="0.5"`, color, color)
+		}
+		if polygon.Opacity < 1 {
+			fmt.Fprintf(&svg, ` opacity="%.2f"`, polygon.Opacity)
+		}
+		svg.WriteString("/>\n")
+	}
+
+	svg.WriteString("</svg>\n")
+	_, err := io.WriteString(w, svg.String())
+	return err
+}
+
+// WriteSVGText writes the characters of the frame in a monospaced grid.
+func WriteSVGText(w io.Writer, frame *render.Frame, opts SVGOptions) error {
+	var svg strings.Builder
+	fg, bg := opts.colors()
+	writeSVGStart(&svg, frame.Cols, frame.Rows, bg)
+	fmt.Fprintf(&svg, `<g font-family="ui-monospace, Menlo, Consolas, monospace" font-size="%d" fill="%s" xml:space="preserve">`+"\n",
+		SVGCellHeight*13/16, fg)
+
+	for row, cells := range frame.Cells {
+		for col := 0; col < len(cells); {
+			colors := [2]matrix.Color{cells[col].Fg, cells[col].Bg}
+			if opts.Monochrome {
+				colors = [2]matrix.Color{}
+			}
+			end := col + 1
+			for end < len(cells) && (opts.Monochrome || (cells[end].Fg == colors[0] && cells[end].Bg == colors[1])) {
+				end++
+			}
+
+			x, y := col*SVGCellWidth, row*SVGCellHeight
+			if !colors[1].IsDefault() {
+				fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					x, y, (end-col)*SVGCellWidth, SVGCellHeight, colors[1].Hex())
+			}
+
+			var text strings.Builder
+			for _, cell := range cells[col:end] {
+				text.WriteRune(cell.Ch)
+			}
+			if strings.TrimSpace(text.String()) != "" {
+				fmt.Fprintf(&svg, `<text x="%d" y="%d" textLength="%d" lengthAdjust="spacingAndGlyphs"`,
+					x, y+SVGCellHeight*3/4, (end-col)*SVGCellWidth)
+				if !colors[0].IsDefault() {
+					fmt.Fprintf(&svg, ` fill="%s"`, colors[0].Hex())
+				}
+				fmt.Fprintf(&svg, ">%s</text>\n", html.EscapeString(text.String()))
+			}
+			col = end
+		}
+	}
+
+	svg.WriteString("</g>\n</svg>\n")
+	_, err := io.WriteString(w, svg.String())
+	return err
+}
+
+// SaveSVGPolygons writes polygons to an SVG file.
+func SaveSVGPolygons(filename string, cols, rows int, polygons []render.Polygon, opts SVGOptions) error {
+	return saveFile(filename, func(w io.Writer) error {
+		return WriteSVGPolygons(w, cols, rows, polygons, opts)
+	})
+}
+
+// SaveSVGText writes the characters of the frame to an SVG file.
+func SaveSVGText(filename string, frame *render.Frame, opts SVGOptions) error {
+	return saveFile(filename, func(w io.Writer) error {
+		return WriteSVGText(w, frame, opts)
+	})
+}
+
+// writeSVGStart opens an SVG picture of cols x rows cells on a background.
+func writeSVGStart(svg *strings.Builder, cols, rows int, background string) {
+	width, height := cols*SVGCellWidth, rows*SVGCellHeight
+	fmt.Fprintf(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(svg, `<rect width="%d" height="%d" fill="%s"/>`+"\n", width, height, background)
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"strings"
+	"testing"
+
+	"zontengine/internal/linalg"
+	"zontengine/internal/matrix"
+	"zontengine/internal/render"
+)
+
+type svgRect struct {
+	X      string `xml:"x,attr"`
+	Y      string `xml:"y,attr"`
+	Width  string `xml:"width,attr"`
+	Height string `xml:"height,attr"`
+	Fill   string `xml:"fill,attr"`
+}
+
+type svgPolygon struct {
+	Points  string `xml:"points,attr"`
+	Fill    string `xml:"fill,attr"`
+	Stroke  string `xml:"stroke,attr"`
+	Opacity string `xml:"opacity,attr"`
+}
+
+type svgText struct {
+	X          string `xml:"x,attr"`
+	Y          string `xml:"y,attr"`
+	TextLength string `xml:"textLength,attr"`
+	Fill       string `xml:"fill,attr"`
+	Text       string `xml:",chardata"`
+}
+
+type svgDocument struct {
+	Width    string       `xml:"width,attr"`
+	Height   string       `xml:"height,attr"`
+	ViewBox  string       `xml:"viewBox,attr"`
+	Rects    []svgRect    `xml:"rect"`
+	Polygons []svgPolygon `xml:"polygon"`
+	Group    struct {
+		Rects []svgRect `xml:"rect"`
+		Texts []svgText `xml:"text"`
+	} `xml:"g"`
+}
+
+func parseSVG(t *testing.T, data []byte) svgDocument {
+	t.Helper()
+	var document svgDocument
+	if err := xml.Unmarshal(data, &document); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, data)
+	}
+	return document
+}
+
+func TestWriteSVGPolygons(t *testing.T) {
+	polygons := []render.Polygon{
+		{
+			Points:  [3]linalg.Vec2{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 5}},
+			Color:   matrix.RGB(255, 0, 0),
+			Opacity: 1,
+		},
+		{
+			Points:  [3]linalg.Vec2{{X: 1.5, Y: 0.25}, {X: 2.125, Y: 4}, {X: 0, Y: 4}},
+			Color:   matrix.RGB(0, 0x80, 0xff),
+			Opacity: 0.5,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		opts    SVGOptions
+		fills   []string
+		strokes []string
+	}{
+		{
+			name:    "filled",
+			fills:   []string{"#ff0000", "#0080ff"},
+			strokes: []string{"#ff0000", "#0080ff"},
+		},
+		{
+			name:    "outline",
+			opts:    SVGOptions{Outline: true, Background: color.White},
+			fills:   []string{"#ffffff", "#ffffff"},
+			strokes: []string{"#ff0000", "#0080ff"},
+		},
+		{
+			name:    "monochrome",
+			opts:    SVGOptions{Monochrome: true},
+			fills:   []string{"#cccccc", "#cccccc"},
+			strokes: []string{"#cccccc", "#cccccc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := WriteSVGPolygons(&out, 10, 5, polygons, tt.opts); err != nil {
+				t.Fatalf("WriteSVGPolygons: %v", err)
+			}
+			document := parseSVG(t, out.Bytes())
+
+			if document.Width != "80" || document.Height != "80" || document.ViewBox != "0 0 80 80" {
+				t.Errorf("picture is %sx%s (%s), want 80x80", document.Width, document.Height, document.ViewBox)
+			}
+			if len(document.Polygons) != len(polygons) {
+				t.Fatalf("got %d polygons, want %d", len(document.Polygons), len(polygons))
+			}
+			wantPoints := []string{"0.00,0.00 80.00,0.00 80.00,80.00", "12.00,4.00 17.00,64.00 0.00,64.00"}
+			wantOpacity := []string{"", "0.50"}
+			for i, polygon := range document.Polygons {
+				if polygon.Points != wantPoints[i] {
+					t.Errorf("polygon %d has points %q, want %q", i, polygon.Points, wantPoints[i])
+				}
+				if polygon.Fill != tt.fills[i] || polygon.Stroke != tt.strokes[i] {
+					t.Errorf("polygon %d is filled %s and stroked %s, want %s and %s",
+						i, polygon.Fill, polygon.Stroke, tt.fills[i], tt.strokes[i])
+				}
+				if polygon.Opacity != wantOpacity[i] {
+					t.Errorf("polygon %d has opacity %q, want %q", i, polygon.Opacity, wantOpacity[i])
+				}
+			}
+
+			// The same input always gives the same file.
+			var again bytes.Buffer
+			if err := WriteSVGPolygons(&again, 10, 5, polygons, tt.opts); err != nil {
+				t.Fatalf("WriteSVGPolygons: %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), again.Bytes()) {
+				t.Error("output differs between two writes")
+			}
+		})
+	}
+}
+
+func TestWriteSVGText(t *testing.T) {
+	red := matrix.RGB(255, 0, 0)
+	frame := render.NewFrame(6, 2)
+	for col, ch := range "<&>ab" {
+		frame.Cells[0][col] = matrix.Cell{Ch: ch}
+	}
+	frame.Cells[0][3].Fg = red
+	frame.Cells[0][4].Fg = red
+	frame.Cells[1][2] = matrix.Cell{Ch: '"', Bg: matrix.RGB(0, 0, 255)}
+
+	var out bytes.Buffer
+	if err := WriteSVGText(&out, frame, SVGOptions{}); err != nil {
+		t.Fatalf("WriteSVGText: %v", err)
+	}
+	if strings.Contains(out.String(), "<&>") {
+		t.Fatalf("cell text is not escaped:\n%s", out.String())
+	}
+	document := parseSVG(t, out.Bytes())
+
+	want := []svgText{
+		{X: "0", Y: "12", TextLength: "24", Text: "<&>"},
+		{X: "24", Y: "12", TextLength: "16", Fill: "#ff0000", Text: "ab"},
+		{X: "16", Y: "28", TextLength: "8", Text: `"`},
+	}
+	if len(document.Group.Texts) != len(want) {
+		t.Fatalf("got %d text runs %v, want %d", len(document.Group.Texts), document.Group.Texts, len(want))
+	}
+	for i, text := range document.Group.Texts {
+		if text != want[i] {
+			t.Errorf("text run %d is %+v, want %+v", i, text, want[i])
+		}
+	}
+
+	wantRects := []svgRect{{X: "16", Y: "16", Width: "8", Height: "16", Fill: "#0000ff"}}
+	if len(document.Group.Rects) != 1 || document.Group.Rects[0] != wantRects[0] {
+		t.Errorf("background rects are %+v, want %+v", document.Group.Rects, wantRects)
+	}
+}
@@ -0,0 +1,249 @@
+package linalg
+
+/**
+ * Fixed-size row-major matrices. Mat4 represents homogeneous transforms
+ * applied to column vectors, so a.Mul(b) applies b first and then a.
+ *
+ * The camera helpers follow the right-handed convention: the eye looks
+ * down its local -Z axis and clip-space z is mapped to [-1, 1] from the
+ * near to the far plane.
+ */
+
+import "math"
+
+type Mat3 [3][3]float64
+
+type Mat4 [4][4]float64
+
+func Identity3() Mat3 {
+	return Mat3{
+		{1, 0, 0},
+		{0, 1, 0},
+		{0, 0, 1},
+	}
+}
+
+func Identity4() Mat4 {
+	return Mat4{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+func (a Mat3) Mul(b Mat3) Mat3 {
+	var result Mat3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			result[i][j] = a[i][0]*b[0][j] + a[i][1]*b[1][j] + a[i][2]*b[2][j]
+		}
+	}
+	return result
+}
+
+func (a Mat3) MulVec(v Vec3) Vec3 {
+	return Vec3{
+		a[0][0]*v.X + a[0][1]*v.Y + a[0][2]*v.Z,
+		a[1][0]*v.X + a[1][1]*v.Y + a[1][2]*v.Z,
+		a[2][0]*v.X + a[2][1]*v.Y + a[2][2]*v.Z,
+	}
+}
+
+func (a Mat3) Transpose() Mat3 {
+	var result Mat3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			result[i][j] = a[j][i]
+		}
+	}
+	return result
+}
+
+func (a Mat3) Determinant() float64 {
+	return a[0][0]*(a[1][1]*a[2][2]-a[1][2]*a[2][1]) -
+		a[0][1]*(a[1][0]*a[2][2]-a[1][2]*a[2][0]) +
+		a[0][2]*(a[1][0]*a[2][1]-a[1][1]*a[2][0])
+}
+
+// Inverse returns the inverse of a and false if a is singular.
+func (a Mat3) Inverse() (Mat3, bool) {
+	det := a.Determinant()
+	if det == 0 {
+		return Mat3{}, false
+	}
+	inv := 1 / det
+	return Mat3{
+		{
+			(a[1][1]*a[2][2] - a[1][2]*a[2][1]) * inv,
+			(a[0][2]*a[2][1] - a[0][1]*a[2][2]) * inv,
+			(a[0][1]*a[1][2] - a[0][2]*a[1][1]) * inv,
+		},
+		{
+			(a[1][2]*a[2][0] - a[1][0]*a[2][2]) * inv,
+			(a[0][0]*a[2][2] - a[0][2]*a[2][0]) * inv,
+			(a[0][2]*a[1][0] - a[0][0]*a[1][2]) * inv,
+		},
+		{
+			(a[1][0]*a[2][1] - a[1][1]*a[2][0]) * inv,
+			(a[0][1]*a[2][0] - a[0][0]*a[2][1]) * inv,
+			(a[0][0]*a[1][1] - a[0][1]*a[1][0]) * inv,
+		},
+	}, true
+}
+
+// Mat4 embeds a as the upper-left block of a homogeneous transform.
+func (a Mat3) Mat4() Mat4 {
+	return Mat4{
+		{a[0][0], a[0][1], a[0][2], 0},
+		{a[1][0], a[1][1], a[1][2], 0},
+		{a[2][0], a[2][1], a[2][2], 0},
+		{0, 0, 0, 1},
+	}
+}
+
+func (a Mat4) Mul(b Mat4) Mat4 {
+	var result Mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result[i][j] = a[i][0]*b[0][j] + a[i][1]*b[1][j] + a[i][2]*b[2][j] + a[i][3]*b[3][j]
+		}
+	}
+	return result
+}
+
+func (a Mat4) MulVec(v Vec4) Vec4 {
+	return Vec4{
+		a[0][0]*v.X + a[0][1]*v.Y + a[0][2]*v.Z + a[0][3]*v.W,
+		a[1][0]*v.X + a[1][1]*v.Y + a[1][2]*v.Z + a[1][3]*v.W,
+		a[2][0]*v.X + a[2][1]*v.Y + a[2][2]*v.Z + a[2][3]*v.W,
+		a[3][0]*v.X + a[3][1]*v.Y + a[3][2]*v.Z + a[3][3]*v.W,
+	}
+}
+
+// MulPoint transforms a point (w=1) and performs the perspective divide.
+func (a Mat4) MulPoint(v Vec3) Vec3 {
+	return a.MulVec(v.Vec4(1)).PerspectiveDivide()
+}
+
+// MulDir transforms a direction (w=0), ignoring translation.
+func (a Mat4) MulDir(v Vec3) Vec3 {
+	return a.MulVec(v.Vec4(0)).Vec3()
+}
+
+func (a Mat4) Transpose() Mat4 {
+	var result Mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result[i][j] = a[j][i]
+		}
+	}
+	return result
+}
+
+// Inverse returns the inverse of a and false if a is singular. It uses
+// Gauss-Jordan elimination with partial pivoting.
+func (a Mat4) Inverse() (Mat4, bool) {
+	m := a
+	inv := Identity4()
+
+	for col := 0; col < 4; col++ {
+		pivot := col
+		for row := col + 1; row < 4; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if m[pivot][col] == 0 {
+			return Mat4{}, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+
+		scale := 1 / m[col][col]
+		for j := 0; j < 4; j++ {
+			m[col][j] *= scale
+			inv[col][j] *= scale
+		}
+
+		for row := 0; row < 4; row++ {
+			if row == col {
+				continue
+			}
+			factor := m[row][col]
+			for j := 0; j < 4; j++ {
+				m[row][j] -= factor * m[col][j]
+				inv[row][j] -= factor * inv[col][j]
+			}
+		}
+	}
+	return inv, true
+}
+
+func Translation(v Vec3) Mat4 {
+	m := Identity4()
+	m[0][3], m[1][3], m[2][3] = v.X, v.Y, v.Z
+	return m
+}
+
+func Scaling(v Vec3) Mat4 {
+	m := Identity4()
+	m[0][0], m[1][1], m[2][2] = v.X, v.Y, v.Z
+	return m
+}
+
+// LookAt returns the world-to-camera transform for an eye at eye looking
+// at target with the given approximate up direction. When up is parallel to
+// the view direction, e.g. for a camera straight above its target, the
+// world axis least aligned with the view direction stands in for it.
+func LookAt(eye, target, up Vec3) Mat4 {
+	f := target.Sub(eye).Normalize()
+	s := f.Cross(up)
+	if s.Len() <= 1e-9*up.Len() {
+		s = f.Cross(leastAlignedAxis(f))
+	}
+	s = s.Normalize()
+	u := s.Cross(f)
+
+	return Mat4{
+		{s.X, s.Y, s.Z, -s.Dot(eye)},
+		{u.X, u.Y, u.Z, -u.Dot(eye)},
+		{-f.X, -f.Y, -f.Z, f.Dot(eye)},
+		{0, 0, 0, 1},
+	}
+}
+
+// leastAlignedAxis returns the unit axis closest to perpendicular to v.
+func leastAlignedAxis(v Vec3) Vec3 {
+	x, y, z := math.Abs(v.X), math.Abs(v.Y), math.Abs(v.Z)
+	switch {
+	case x <= y && x <= z:
+		return Vec3{X: 1}
+	case y <= z:
+		return Vec3{Y: 1}
+	}
+	return Vec3{Z: 1}
+}
+
+// Perspective returns the camera-to-clip transform for a vertical field of
+// view fovY in radians and a width/height aspect ratio.
+func Perspective(fovY, aspect, near, far float64) Mat4 {
+	t := 1 / math.Tan(fovY/2)
+	return Mat4{
+		{t / aspect, 0, 0, 0},
+		{0, t, 0, 0},
+		{0, 0, -(far + near) / (far - near), -2 * far * near / (far - near)},
+		{0, 0, -1, 0},
+	}
+}
+
+// Orthographic returns the camera-to-clip transform for a box of the given
+// half extents.
+func Orthographic(halfWidth, halfHeight, near, far float64) Mat4 {
+	return Mat4{
+		{1 / halfWidth, 0, 0, 0},
+		{0, 1 / halfHeight, 0, 0},
+		{0, 0, -2 / (far - near), -(far + near) / (far - near)},
+		{0, 0, 0, 1},
+	}
+}
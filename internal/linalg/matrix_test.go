@@ -0,0 +1,194 @@
+package linalg
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func near(a, b Vec3) bool {
+	return math.Abs(a.X-b.X) < epsilon && math.Abs(a.Y-b.Y) < epsilon && math.Abs(a.Z-b.Z) < epsilon
+}
+
+func TestLookAt(t *testing.T) {
+	tests := []struct {
+		name            string
+		eye, target, up Vec3
+		// right and screenUp are the world directions of camera +X and +Y,
+		// zero when any will do.
+		right, screenUp Vec3
+	}{
+		{
+			name: "from +Z",
+			eye:  Vec3{Z: 3}, up: Vec3{Y: 1},
+			right: Vec3{X: 1}, screenUp: Vec3{Y: 1},
+		},
+		{
+			name: "from -Z",
+			eye:  Vec3{Z: -3}, up: Vec3{Y: 1},
+			right: Vec3{X: -1}, screenUp: Vec3{Y: 1},
+		},
+		{
+			name: "off-centre target",
+			eye:  Vec3{X: 1, Y: 2, Z: 5}, target: Vec3{X: 1, Y: 2}, up: Vec3{Y: 2},
+			right: Vec3{X: 1}, screenUp: Vec3{Y: 1},
+		},
+		// Up is parallel to the view direction or missing: any roll will
+		// do, as long as the basis stays orthonormal.
+		{name: "straight above", eye: Vec3{Y: 4}, up: Vec3{Y: 1}},
+		{name: "straight below", eye: Vec3{Y: -4}, up: Vec3{Y: 1}},
+		{name: "no up", eye: Vec3{Z: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := LookAt(tt.eye, tt.target, tt.up)
+			distance := tt.target.Sub(tt.eye).Len()
+
+			// The target lies straight ahead, down the camera's -Z axis.
+			if got := view.MulPoint(tt.target); !near(got, Vec3{Z: -distance}) {
+				t.Errorf("target maps to %v, want (0, 0, %v)", got, -distance)
+			}
+
+			axes := [3]Vec3{view.MulDir(Vec3{X: 1}), view.MulDir(Vec3{Y: 1}), view.MulDir(Vec3{Z: 1})}
+			for i, axis := range axes {
+				if math.Abs(axis.Len()-1) > epsilon || math.Abs(axis.Dot(axes[(i+1)%3])) > epsilon {
+					t.Fatalf("rotation is not orthonormal: %v", axes)
+				}
+			}
+
+			if tt.right == (Vec3{}) {
+				return
+			}
+			if got := view.MulDir(tt.right); !near(got, Vec3{X: 1}) {
+				t.Errorf("right %v maps to %v, want +X", tt.right, got)
+			}
+			if got := view.MulDir(tt.screenUp); !near(got, Vec3{Y: 1}) {
+				t.Errorf("up %v maps to %v, want +Y", tt.screenUp, got)
+			}
+		})
+	}
+}
+
+func nearMat4(a, b Mat4) bool {
+	for row := range a {
+		for col := range a[row] {
+			if math.Abs(a[row][col]-b[row][col]) > epsilon {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestMat4Inverse(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Mat4
+	}{
+		{"identity", Identity4()},
+		{"translation and scaling", Translation(Vec3{X: 1, Y: -2, Z: 3}).Mul(Scaling(Vec3{X: 2, Y: 0.5, Z: -4}))},
+		{"look at", LookAt(Vec3{X: 1, Y: 2, Z: 5}, Vec3{Y: -1}, Vec3{Y: 1})},
+		{"perspective", Perspective(math.Pi/4, 2, 0.1, 100)},
+		{"orthographic", Orthographic(3, 2, 0.1, 100)},
+		// Zeros on the diagonal need row swaps.
+		{"permutation", Mat4{{0, 1, 0, 0}, {0, 0, 0, 2}, {3, 0, 0, 0}, {0, 0, 4, 0}}},
+		{"dense", Mat4{{2, -1, 0, 3}, {1, 4, -2, 0}, {0, 5, 1, -1}, {-3, 0, 2, 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inverse, ok := tt.m.Inverse()
+			if !ok {
+				t.Fatal("Inverse reported a singular matrix")
+			}
+			if product := tt.m.Mul(inverse); !nearMat4(product, Identity4()) {
+				t.Errorf("M * M^-1 = %v, want the identity", product)
+			}
+			if product := inverse.Mul(tt.m); !nearMat4(product, Identity4()) {
+				t.Errorf("M^-1 * M = %v, want the identity", product)
+			}
+		})
+	}
+}
+
+func TestMat4InverseSingular(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Mat4
+	}{
+		{"zero", Mat4{}},
+		{"flattening", Scaling(Vec3{X: 1, Y: 0, Z: 1})},
+		{"dependent rows", Mat4{{1, 2, 3, 4}, {2, 4, 6, 8}, {0, 0, 1, 0}, {0, 0, 0, 1}}},
+		{"projection without w", Mat4{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if inverse, ok := tt.m.Inverse(); ok || inverse != (Mat4{}) {
+				t.Errorf("Inverse = %v, %v; want the zero matrix, false", inverse, ok)
+			}
+		})
+	}
+}
+
+// TestProjectionDepth checks that the near and far planes land on the
+// faces of the NDC cube, z = -1 and z = +1, and the frustum edges on its
+// sides.
+func TestProjectionDepth(t *testing.T) {
+	const nearPlane, farPlane = 0.1, 100.0
+	fovY, aspect := math.Pi/3, 1.5
+	halfHeight := math.Tan(fovY / 2)
+
+	tests := []struct {
+		name       string
+		projection Mat4
+		// edge returns the top right corner of the view at a distance.
+		edge func(distance float64) Vec3
+	}{
+		{
+			name:       "perspective",
+			projection: Perspective(fovY, aspect, nearPlane, farPlane),
+			edge: func(distance float64) Vec3 {
+				return Vec3{X: halfHeight * aspect * distance, Y: halfHeight * distance, Z: -distance}
+			},
+		},
+		{
+			name:       "orthographic",
+			projection: Orthographic(3, 2, nearPlane, farPlane),
+			edge: func(distance float64) Vec3 {
+				return Vec3{X: 3, Y: 2, Z: -distance}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planes := []struct {
+				distance, z float64
+			}{
+				{nearPlane, -1},
+				{farPlane, 1},
+			}
+			for _, plane := range planes {
+				if got := tt.projection.MulPoint(Vec3{Z: -plane.distance}); !near(got, Vec3{Z: plane.z}) {
+					t.Errorf("centre at distance %v maps to %v, want (0, 0, %v)", plane.distance, got, plane.z)
+				}
+				if got := tt.projection.MulPoint(tt.edge(plane.distance)); !near(got, Vec3{X: 1, Y: 1, Z: plane.z}) {
+					t.Errorf("corner at distance %v maps to %v, want (1, 1, %v)", plane.distance, got, plane.z)
+				}
+			}
+
+			// Depth grows monotonically from the near to the far plane.
+			previous := -1.0
+			for distance := 0.5; distance < farPlane; distance *= 2 {
+				z := tt.projection.MulPoint(Vec3{Z: -distance}).Z
+				if z <= previous || z >= 1 {
+					t.Errorf("distance %v maps to z = %v after %v", distance, z, previous)
+				}
+				previous = z
+			}
+		})
+	}
+}
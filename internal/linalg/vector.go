@@ -0,0 +1,101 @@
+package linalg
+
+/**
+ * Fixed-size vectors for 3D math. All types are plain values, so passing
+ * and returning them never allocates and they can be used as map keys.
+ */
+
+import "math"
+
+type Vec2 struct {
+	X, Y float64
+}
+
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+type Vec4 struct {
+	X, Y, Z, W float64
+}
+
+func (a Vec2) Add(b Vec2) Vec2 {
+	return Vec2{a.X + b.X, a.Y + b.Y}
+}
+
+func (a Vec2) Scale(s float64) Vec2 {
+	return Vec2{a.X * s, a.Y * s}
+}
+
+func (a Vec3) Add(b Vec3) Vec3 {
+	return Vec3{a.X + b.X, a.Y + b.Y, a.Z + b.Z}
+}
+
+func (a Vec3) Sub(b Vec3) Vec3 {
+	return Vec3{a.X - b.X, a.Y - b.Y, a.Z - b.Z}
+}
+
+func (a Vec3) Scale(s float64) Vec3 {
+	return Vec3{a.X * s, a.Y * s, a.Z * s}
+}
+
+// Mul multiplies a and b component by component, e.g. to modulate colors.
+func (a Vec3) Mul(b Vec3) Vec3 {
+	return Vec3{a.X * b.X, a.Y * b.Y, a.Z * b.Z}
+}
+
+func (a Vec3) Neg() Vec3 {
+	return Vec3{-a.X, -a.Y, -a.Z}
+}
+
+func (a Vec3) Dot(b Vec3) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func (a Vec3) Cross(b Vec3) Vec3 {
+	return Vec3{
+		a.Y*b.Z - a.Z*b.Y,
+		a.Z*b.X - a.X*b.Z,
+		a.X*b.Y - a.Y*b.X,
+	}
+}
+
+func (a Vec3) Len() float64 {
+	return math.Sqrt(a.Dot(a))
+}
+
+// Normalize returns a scaled to unit length, or the zero vector for a zero a.
+func (a Vec3) Normalize() Vec3 {
+	length := a.Len()
+	if length == 0 {
+		return Vec3{}
+	}
+	return a.Scale(1 / length)
+}
+
+// Lerp interpolates linearly from a (t=0) to b (t=1).
+func (a Vec3) Lerp(b Vec3, t float64) Vec3 {
+	return a.Add(b.Sub(a).Scale(t))
+}
+
+// Vec4 extends a to homogeneous coordinates with the given w.
+func (a Vec3) Vec4(w float64) Vec4 {
+	return Vec4{a.X, a.Y, a.Z, w}
+}
+
+func (a Vec4) Dot(b Vec4) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z + a.W*b.W
+}
+
+// Vec3 drops w without dividing.
+func (a Vec4) Vec3() Vec3 {
+	return Vec3{a.X, a.Y, a.Z}
+}
+
+// PerspectiveDivide returns (x, y, z) / w, or (x, y, z) when w is zero.
+func (a Vec4) PerspectiveDivide() Vec3 {
+	if a.W == 0 {
+		return a.Vec3()
+	}
+	return Vec3{a.X / a.W, a.Y / a.W, a.Z / a.W}
+}
@@ -0,0 +1,62 @@
+package matrix
+
+/**
+ * Screen buffer cells: a character with optional foreground and background
+ * colors. Colors are stored as 24-bit RGB and reduced to whatever the
+ * terminal supports only when the screen is drawn.
+ */
+
+import (
+	"fmt"
+	"zontengine/internal/linalg"
+)
+
+// Color is a 24-bit RGB color. The zero value is DefaultColor, which keeps
+// the terminal's own foreground or background.
+type Color uint32
+
+const DefaultColor Color = 0
+
+// colorSet marks a color as explicitly chosen, so that black differs from
+// DefaultColor.
+const colorSet Color = 1 << 24
+
+func RGB(r, g, b uint8) Color {
+	return colorSet | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// ColorFromVec3 converts a linear color with components in [0, 1] (values
+// outside are clamped) to a Color.
+func ColorFromVec3(v linalg.Vec3) Color {
+	return RGB(
+		uint8(Clamp(v.X*255+0.5, 0, 255)),
+		uint8(Clamp(v.Y*255+0.5, 0, 255)),
+		uint8(Clamp(v.Z*255+0.5, 0, 255)),
+	)
+}
+
+func (c Color) IsDefault() bool {
+	return c&colorSet == 0
+}
+
+func (c Color) RGB() (r, g, b uint8) {
+	return uint8(c >> 16), uint8(c >> 8), uint8(c)
+}
+
+// Hex formats the color as "#rrggbb".
+func (c Color) Hex() string {
+	r, g, b := c.RGB()
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+type Cell struct {
+	Ch rune
+	Fg Color
+	Bg Color
+}
+
+// BlankCell is an empty cell in the terminal's default colors.
+var BlankCell = Cell{Ch: ' '}
+
+// NewlineCell ends every row of a screen buffer.
+var NewlineCell = Cell{Ch: '\n'}
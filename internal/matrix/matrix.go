@@ -7,26 +7,40 @@ package matrix
  * @param cols          number of columns in the terminal screen
  * @param rows          number of rows in the terminal screen
  * @param angle         current rotation angle for 3D transformations
- * @param ScreenBuffer  double buffer system for smooth rendering [0]=current, [1]=previous
+ * @param screenBuffer  triple buffer of cells (see cell.go) handed between the
+ *                      renderer and the screen
+ * @param DepthBuffer   per-cell depth of the closest fragment written to Back()
  *
  * The matrix supports:
- * - Matrix multiplication for 3D transformations
  * - Z-depth sorting of triangles for proper rendering order
- * - Screen buffer management with double buffering
+ * - Per-cell depth testing as an alternative to sorting
+ * - Screen buffer management with triple buffering: the renderer owns Back(),
+ *   Present() publishes it, and the screen owns whatever AcquireFront()
+ *   returned until its next call, so neither side ever sees a half-drawn frame
  * - Clamping values to specified ranges
- * - Managing screen dimensions and rotation state
+ * - Managing screen dimensions and rotation state, including resizing all
+ *   buffers while the renderer is running
  */
 
 import (
 	"math"
 	"sort"
+	"sync"
+	"zontengine/internal/linalg"
 )
 
 type Matrix struct {
 	cols         int
 	rows         int
 	angle        float64
-	ScreenBuffer [2][][]rune
+	screenBuffer [3][][]Cell
+	DepthBuffer  [][]float64
+
+	swapMutex sync.Mutex
+	back      int
+	ready     int
+	front     int
+	fresh     bool
 }
 
 func NewMatrix(cols, rows int) *Matrix {
@@ -34,73 +48,152 @@ func NewMatrix(cols, rows int) *Matrix {
 		cols:  cols,
 		rows:  rows,
 		angle: 0,
+		back:  0,
+		ready: 1,
+		front: 2,
 	}
 
-	m.ScreenBuffer[0] = make([][]rune, rows)
-	m.ScreenBuffer[1] = make([][]rune, rows)
-	for i := 0; i < rows; i++ {
-		m.ScreenBuffer[0][i] = make([]rune, cols+1)
-		m.ScreenBuffer[1][i] = make([]rune, cols+1)
+	for i := range m.screenBuffer {
+		m.screenBuffer[i] = newScreenBuffer(cols, rows)
 	}
+	m.DepthBuffer = NewDepthBuffer(cols, rows)
 
 	return m
 }
 
-func Clamp(value float64, min, max int) int {
-	return int(math.Min(float64(max), math.Max(float64(min), value)))
+// newScreenBuffer returns a blank buffer; every row ends with a newline in
+// the extra column so the whole buffer can be printed as is.
+func newScreenBuffer(cols, rows int) [][]Cell {
+	buffer := make([][]Cell, rows)
+	for i := range buffer {
+		buffer[i] = make([]Cell, cols+1)
+		for j := 0; j < cols; j++ {
+			buffer[i][j] = BlankCell
+		}
+		buffer[i][cols] = NewlineCell
+	}
+	return buffer
 }
 
-func MultiplyMatrices(matrixA, matrixB [][]float64) [][]float64 {
-	result := make([][]float64, len(matrixA))
-	for i := range result {
-		result[i] = make([]float64, len(matrixB[0]))
-	}
+// Resize reallocates every buffer for a cols x rows screen. Like Back, it
+// may only be called by the renderer between frames. A frame presented but
+// not yet acquired is dropped, and a frame the screen still holds keeps its
+// old size until it acquires the next one.
+func (m *Matrix) Resize(cols, rows int) {
+	m.swapMutex.Lock()
+	defer m.swapMutex.Unlock()
 
-	for i := 0; i < len(matrixA); i++ {
-		for j := 0; j < len(matrixB[0]); j++ {
-			for k := 0; k < len(matrixA[0]); k++ {
-				result[i][j] += matrixA[i][k] * matrixB[k][j]
-			}
-		}
+	if cols == m.cols && rows == m.rows {
+		return
 	}
-	return result
+	m.cols, m.rows = cols, rows
+	for i := range m.screenBuffer {
+		m.screenBuffer[i] = newScreenBuffer(cols, rows)
+	}
+	m.DepthBuffer = NewDepthBuffer(cols, rows)
+	m.fresh = false
 }
 
-type Triangle struct {
-	verts [4][]float64
-	avgZ  float64
+// Back returns the buffer the renderer draws the next frame into. Only the
+// renderer may touch it, and only until it calls Present.
+func (m *Matrix) Back() [][]Cell {
+	m.swapMutex.Lock()
+	defer m.swapMutex.Unlock()
+	return m.screenBuffer[m.back]
 }
 
-func (m *Matrix) SortVerts(verts [][]float64) [][]float64 {
-	var triangles []Triangle
-
-	for i := 0; i < len(verts); i += 4 {
-		if i+3 < len(verts) {
-			triangle := Triangle{
-				verts: [4][]float64{verts[i], verts[i+1], verts[i+2], verts[i+3]},
-			}
+// Present publishes the back buffer as the newest complete frame and gives
+// the renderer a free buffer to draw the following one into. A published
+// frame that the screen has not picked up yet is dropped.
+func (m *Matrix) Present() {
+	m.swapMutex.Lock()
+	defer m.swapMutex.Unlock()
+	m.back, m.ready = m.ready, m.back
+	m.fresh = true
+}
 
-			triangle.avgZ = (verts[i][2] + verts[i+1][2] + verts[i+2][2]) / 3.0
-			triangles = append(triangles, triangle)
-		}
+// AcquireFront returns the newest complete frame for display and whether it
+// is new since the previous call. The returned buffer belongs to the caller
+// until the next AcquireFront.
+func (m *Matrix) AcquireFront() ([][]Cell, bool) {
+	m.swapMutex.Lock()
+	defer m.swapMutex.Unlock()
+	fresh := m.fresh
+	if fresh {
+		m.front, m.ready = m.ready, m.front
+		m.fresh = false
 	}
+	return m.screenBuffer[m.front], fresh
+}
 
-	sort.Slice(triangles, func(i, j int) bool {
-		return triangles[i].avgZ > triangles[j].avgZ
-	})
+func NewDepthBuffer(cols, rows int) [][]float64 {
+	depth := make([][]float64, rows)
+	for i := range depth {
+		depth[i] = make([]float64, cols)
+	}
+	ClearDepth(depth)
+	return depth
+}
 
-	result := make([][]float64, len(verts))
-	idx := 0
-	for _, triangle := range triangles {
-		for _, vert := range triangle.verts {
-			if idx < len(result) {
-				result[idx] = vert
-				idx++
-			}
+// ClearDepth resets every cell to +Inf so that any fragment passes the first test.
+func ClearDepth(depth [][]float64) {
+	for row := range depth {
+		for col := range depth[row] {
+			depth[row][col] = math.Inf(1)
 		}
 	}
+}
+
+// DepthTest reports whether z is closer (smaller) than the stored depth at
+// the given cell and, if so, records it.
+func DepthTest(depth [][]float64, col, row int, z float64) bool {
+	if row < 0 || row >= len(depth) || col < 0 || col >= len(depth[row]) {
+		return false
+	}
+	if z >= depth[row][col] {
+		return false
+	}
+	depth[row][col] = z
+	return true
+}
+
+func (m *Matrix) ClearDepth() {
+	ClearDepth(m.DepthBuffer)
+}
+
+func Clamp(value float64, min, max int) int {
+	return int(math.Min(float64(max), math.Max(float64(min), value)))
+}
+
+// Triangle is a visible face ready to be drawn: its world-space vertices,
+// unit face normal, the same vertices in normalized device coordinates and,
+// when Smooth is set, per-vertex normals to interpolate across the face.
+// UVs are valid when Textured is set and the vertex colors in Colors when
+// Colored is set; Material indexes the mesh materials.
+type Triangle struct {
+	Verts     [3]linalg.Vec3
+	Normal    linalg.Vec3
+	Projected [3]linalg.Vec3
+	Normals   [3]linalg.Vec3
+	Smooth    bool
+	UVs       [3]linalg.Vec2
+	Textured  bool
+	Colors    [3]linalg.Vec3
+	Colored   bool
+	Material  int
+}
+
+// SortVerts orders triangles back to front by their average projected depth,
+// in place, and returns the same slice.
+func (m *Matrix) SortVerts(triangles []Triangle) []Triangle {
+	sort.SliceStable(triangles, func(i, j int) bool {
+		return avgDepth(triangles[i]) > avgDepth(triangles[j])
+	})
+	return triangles
+}
 
-	return result
+func avgDepth(t Triangle) float64 {
+	return (t.Projected[0].Z + t.Projected[1].Z + t.Projected[2].Z) / 3.0
 }
 
 func (m *Matrix) GetCols() int {
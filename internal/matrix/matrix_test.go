@@ -0,0 +1,147 @@
+package matrix
+
+import (
+	"sync"
+	"testing"
+)
+
+// stamp fills every cell of a frame except the newline column with ch.
+func stamp(buffer [][]Cell, ch rune) {
+	for _, row := range buffer {
+		for col := 0; col < len(row)-1; col++ {
+			row[col] = Cell{Ch: ch}
+		}
+	}
+}
+
+// frameStamp returns the character a frame was stamped with, failing the
+// test if the frame mixes characters or lost its newline column.
+func frameStamp(t *testing.T, buffer [][]Cell) rune {
+	t.Helper()
+	ch := buffer[0][0].Ch
+	for _, row := range buffer {
+		if len(row) != len(buffer[0]) {
+			t.Fatalf("rows of different lengths %d and %d", len(row), len(buffer[0]))
+		}
+		for col, cell := range row {
+			if col == len(row)-1 {
+				if cell != NewlineCell {
+					t.Fatalf("last column holds %q, want a newline", cell.Ch)
+				}
+				continue
+			}
+			if cell.Ch != ch {
+				t.Fatalf("torn frame: %q and %q in one buffer", ch, cell.Ch)
+			}
+		}
+	}
+	return ch
+}
+
+func TestFrameHandoff(t *testing.T) {
+	m := NewMatrix(4, 3)
+
+	if _, fresh := m.AcquireFront(); fresh {
+		t.Fatal("AcquireFront reported a fresh frame before any Present")
+	}
+
+	stamp(m.Back(), 'a')
+	m.Present()
+	front, fresh := m.AcquireFront()
+	if !fresh || frameStamp(t, front) != 'a' {
+		t.Fatalf("AcquireFront = %q, %v; want 'a', true", front[0][0].Ch, fresh)
+	}
+
+	// The renderer must never be handed the buffer the screen holds.
+	back := m.Back()
+	if &back[0][0] == &front[0][0] {
+		t.Fatal("Back returned the buffer owned by the screen")
+	}
+
+	again, fresh := m.AcquireFront()
+	if fresh || &again[0][0] != &front[0][0] {
+		t.Fatal("a second AcquireFront without Present changed the frame")
+	}
+
+	// Of two frames presented in a row only the newest is shown.
+	stamp(m.Back(), 'b')
+	m.Present()
+	stamp(m.Back(), 'c')
+	m.Present()
+	front, fresh = m.AcquireFront()
+	if !fresh || frameStamp(t, front) != 'c' {
+		t.Fatalf("AcquireFront = %q, %v; want 'c', true", front[0][0].Ch, fresh)
+	}
+}
+
+// handoff runs a renderer presenting frames stamped 1..frames, calling
+// between before each one, against a screen acquiring them concurrently.
+// It checks that the screen never sees a torn frame or an older frame after
+// a newer one.
+func handoff(t *testing.T, m *Matrix, frames int, between func(frame int)) {
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for frame := 1; frame <= frames; frame++ {
+			between(frame)
+			stamp(m.Back(), rune(frame))
+			m.Present()
+		}
+	}()
+
+	var last rune
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		front, fresh := m.AcquireFront()
+		if !fresh {
+			continue
+		}
+		ch := frameStamp(t, front)
+		if ch <= last {
+			t.Fatalf("frame %d shown after frame %d", ch, last)
+		}
+		last = ch
+	}
+	wg.Wait()
+
+	front, _ := m.AcquireFront()
+	if got := frameStamp(t, front); got != rune(frames) {
+		t.Fatalf("last frame shown is %d, want %d", got, frames)
+	}
+}
+
+func TestConcurrentHandoff(t *testing.T) {
+	handoff(t, NewMatrix(16, 8), 2000, func(int) {})
+}
+
+func TestResizeWhilePresented(t *testing.T) {
+	m := NewMatrix(16, 8)
+	handoff(t, m, 1000, func(frame int) {
+		if frame%10 == 0 {
+			m.Resize(8+frame%7, 4+frame%5)
+		}
+	})
+}
+
+func TestResizeDropsPendingFrame(t *testing.T) {
+	m := NewMatrix(4, 3)
+	stamp(m.Back(), 'a')
+	m.Present()
+	m.Resize(6, 2)
+
+	front, fresh := m.AcquireFront()
+	if fresh {
+		t.Fatal("a frame presented before Resize was still shown")
+	}
+	if len(front) != 2 || len(front[0]) != 7 {
+		t.Fatalf("front is %dx%d after Resize, want 2 rows of 7 cells", len(front), len(front[0]))
+	}
+}
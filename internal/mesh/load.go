@@ -0,0 +1,102 @@
+package mesh
+
+/**
+ * Loading a model file of any supported format.
+ *
+ * The format comes from the file extension when it is a known one, and
+ * otherwise from the first bytes of the file: the "ply" magic line means
+ * PLY, the "solid" keyword or a size large enough for the triangles a binary
+ * STL header announces mean STL, anything else is read as OBJ.
+ */
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a model file format.
+type Format int
+
+const (
+	FormatOBJ Format = iota
+	FormatSTL
+	FormatPLY
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatSTL:
+		return "STL"
+	case FormatPLY:
+		return "PLY"
+	}
+	return "OBJ"
+}
+
+// formatsByExtension maps lower-case file extensions to their format.
+var formatsByExtension = map[string]Format{
+	".obj": FormatOBJ,
+	".stl": FormatSTL,
+	".ply": FormatPLY,
+}
+
+// Load reads a model, detecting its format with DetectFormat.
+func Load(filename string) (*Mesh, error) {
+	format, err := DetectFormat(filename)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatSTL:
+		return LoadSTL(filename)
+	case FormatPLY:
+		return LoadPLY(filename)
+	}
+	return LoadOBJ(filename)
+}
+
+// DetectFormat returns the format of a model file from its extension or,
+// for other extensions, from its content.
+func DetectFormat(filename string) (Format, error) {
+	if format, exists := formatsByExtension[strings.ToLower(filepath.Ext(filename))]; exists {
+		return format, nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return FormatOBJ, err
+	}
+	defer file.Close()
+
+	head := make([]byte, stlHeaderSize+4)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return FormatOBJ, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	head = head[:n]
+
+	info, err := file.Stat()
+	if err != nil {
+		return FormatOBJ, err
+	}
+	return sniffFormat(head, info.Size()), nil
+}
+
+// sniffFormat guesses the format from the first bytes and the size of a file.
+func sniffFormat(head []byte, size int64) Format {
+	if bytes.HasPrefix(head, []byte("ply\n")) || bytes.HasPrefix(head, []byte("ply\r\n")) {
+		return FormatPLY
+	}
+	if looksLikeASCIISTL(head) {
+		return FormatSTL
+	}
+	// Binary files may be padded past their triangles.
+	if stlSize, ok := binarySTLSize(head); ok && stlSize <= size {
+		return FormatSTL
+	}
+	return FormatOBJ
+}
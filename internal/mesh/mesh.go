@@ -0,0 +1,178 @@
+package mesh
+
+/**
+ * Indexed triangle meshes shared by the model loaders and the renderer.
+ *
+ * @param Positions  vertex positions
+ * @param Normals    vertex normals referenced by faces (may be empty)
+ * @param UVs        texture coordinates referenced by faces (may be empty)
+ * @param Colors     per-vertex colors parallel to Positions (may be empty)
+ * @param Faces      triangles indexing into the attribute arrays
+ * @param Groups     object/group/material runs that faces belong to
+ * @param Materials  materials referenced by faces (may be empty)
+ *
+ * Attributes are stored once and faces refer to them by index, so shared
+ * vertices are not duplicated. Face attribute and material indices are -1
+ * when a face does not provide them.
+ */
+
+import (
+	"fmt"
+
+	"zontengine/internal/linalg"
+)
+
+type Face struct {
+	Positions [3]int
+	Normals   [3]int
+	UVs       [3]int
+	// Group indexes Mesh.Groups.
+	Group int
+	// Smooth is the smoothing group. Faces in group 0 are shaded flat even
+	// when their corners have normals; other faces interpolate them.
+	Smooth int
+	// Material indexes Mesh.Materials.
+	Material int
+}
+
+// Group is a run of faces sharing the same object, group and material names.
+type Group struct {
+	Object   string
+	Name     string
+	Material string
+}
+
+type Mesh struct {
+	Name      string
+	Positions []linalg.Vec3
+	Normals   []linalg.Vec3
+	UVs       []linalg.Vec2
+	// Colors holds an RGB color in 0..1 for every position, or nothing.
+	Colors       []linalg.Vec3
+	Faces        []Face
+	Groups       []Group
+	Materials    []Material
+	MaterialLibs []string
+}
+
+var defaultMaterial = DefaultMaterial()
+
+// MaterialAt returns Materials[index], or a shared default material for an
+// index of -1 or any other index out of range. The result must not be
+// modified.
+func (m *Mesh) MaterialAt(index int) *Material {
+	if index < 0 || index >= len(m.Materials) {
+		return &defaultMaterial
+	}
+	return &m.Materials[index]
+}
+
+// HasUVs reports whether every corner of face i has a texture coordinate.
+func (m *Mesh) HasUVs(i int) bool {
+	f := m.Faces[i]
+	return f.UVs[0] >= 0 && f.UVs[1] >= 0 && f.UVs[2] >= 0
+}
+
+// SetMaterials replaces the material list and points every face at the
+// material named by its group; faces whose material is unknown get -1.
+func (m *Mesh) SetMaterials(materials []Material) {
+	m.Materials = materials
+
+	byName := make(map[string]int, len(materials))
+	for i, material := range materials {
+		byName[material.Name] = i
+	}
+	for i := range m.Faces {
+		m.Faces[i].Material = -1
+		group := m.Faces[i].Group
+		if group < 0 || group >= len(m.Groups) {
+			continue
+		}
+		if index, exists := byName[m.Groups[group].Material]; exists {
+			m.Faces[i].Material = index
+		}
+	}
+}
+
+// HasColors reports whether every position has a vertex color.
+func (m *Mesh) HasColors() bool {
+	return len(m.Colors) > 0 && len(m.Colors) == len(m.Positions)
+}
+
+// HasNormals reports whether every corner of face i has a vertex normal.
+func (m *Mesh) HasNormals(i int) bool {
+	f := m.Faces[i]
+	return f.Normals[0] >= 0 && f.Normals[1] >= 0 && f.Normals[2] >= 0
+}
+
+// Triangle returns the three positions of face i.
+func (m *Mesh) Triangle(i int) (linalg.Vec3, linalg.Vec3, linalg.Vec3) {
+	f := m.Faces[i]
+	return m.Positions[f.Positions[0]], m.Positions[f.Positions[1]], m.Positions[f.Positions[2]]
+}
+
+// Bounds returns the axis-aligned bounding box of all positions.
+func (m *Mesh) Bounds() (min, max linalg.Vec3) {
+	if len(m.Positions) == 0 {
+		return
+	}
+	min, max = m.Positions[0], m.Positions[0]
+	for _, p := range m.Positions[1:] {
+		min.X, max.X = minMax(min.X, max.X, p.X)
+		min.Y, max.Y = minMax(min.Y, max.Y, p.Y)
+		min.Z, max.Z = minMax(min.Z, max.Z, p.Z)
+	}
+	return
+}
+
+func minMax(lo, hi, v float64) (float64, float64) {
+	if v < lo {
+		lo = v
+	}
+	if v > hi {
+		hi = v
+	}
+	return lo, hi
+}
+
+// Validate checks that every face index refers to an existing attribute.
+func (m *Mesh) Validate() error {
+	if len(m.Colors) > 0 && len(m.Colors) != len(m.Positions) {
+		return fmt.Errorf("%d vertex colors for %d positions", len(m.Colors), len(m.Positions))
+	}
+	for i, f := range m.Faces {
+		for c := 0; c < 3; c++ {
+			if f.Positions[c] < 0 || f.Positions[c] >= len(m.Positions) {
+				return fmt.Errorf("face %d: position index %d out of range", i, f.Positions[c])
+			}
+			if f.Normals[c] >= len(m.Normals) {
+				return fmt.Errorf("face %d: normal index %d out of range", i, f.Normals[c])
+			}
+			if f.UVs[c] >= len(m.UVs) {
+				return fmt.Errorf("face %d: texture index %d out of range", i, f.UVs[c])
+			}
+		}
+		if f.Group < 0 || f.Group >= len(m.Groups) {
+			return fmt.Errorf("face %d: group index %d out of range", i, f.Group)
+		}
+		if f.Material >= len(m.Materials) {
+			return fmt.Errorf("face %d: material index %d out of range", i, f.Material)
+		}
+	}
+	return nil
+}
+
+// ParseError reports a malformed line in a model file.
+type ParseError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
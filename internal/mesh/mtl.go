@@ -0,0 +1,225 @@
+package mesh
+
+/**
+ * Wavefront MTL material library reader.
+ *
+ * Supported statements: newmtl, Ka, Kd, Ks, Ns, d, Tr and map_Kd. Texture
+ * paths are resolved relative to the library and decoded (PNG or JPEG)
+ * when the library is loaded from disk; a texture file that does not exist
+ * leaves its material untextured. Other statements are skipped.
+ */
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"zontengine/internal/linalg"
+)
+
+type Material struct {
+	Name      string
+	Ambient   linalg.Vec3
+	Diffuse   linalg.Vec3
+	Specular  linalg.Vec3
+	Shininess float64
+	// Dissolve is the opacity, 1 being fully opaque.
+	Dissolve   float64
+	DiffuseMap string
+	// Texture is the decoded DiffuseMap, or nil.
+	Texture image.Image
+}
+
+// DefaultMaterial is used for faces without a material: a white, fully
+// diffuse and opaque surface.
+func DefaultMaterial() Material {
+	return Material{
+		Diffuse:  linalg.Vec3{X: 1, Y: 1, Z: 1},
+		Dissolve: 1,
+	}
+}
+
+// SampleDiffuse returns the diffuse color at texture coordinate uv: Kd
+// modulated by the texture (nearest texel, wrapping) when there is one.
+func (m *Material) SampleDiffuse(uv linalg.Vec2) linalg.Vec3 {
+	if m.Texture == nil {
+		return m.Diffuse
+	}
+	bounds := m.Texture.Bounds()
+	u := uv.X - math.Floor(uv.X)
+	v := uv.Y - math.Floor(uv.Y)
+	x := bounds.Min.X + int(u*float64(bounds.Dx()))
+	y := bounds.Min.Y + int((1-v)*float64(bounds.Dy()))
+	if x >= bounds.Max.X {
+		x = bounds.Max.X - 1
+	}
+	if y >= bounds.Max.Y {
+		y = bounds.Max.Y - 1
+	}
+	r, g, b, _ := m.Texture.At(x, y).RGBA()
+	return linalg.Vec3{
+		X: m.Diffuse.X * float64(r) / 0xffff,
+		Y: m.Diffuse.Y * float64(g) / 0xffff,
+		Z: m.Diffuse.Z * float64(b) / 0xffff,
+	}
+}
+
+// LoadMTL reads a material library and decodes the textures it references.
+func LoadMTL(filename string) ([]Material, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	materials, err := ParseMTL(file, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	for i := range materials {
+		if materials[i].DiffuseMap == "" {
+			continue
+		}
+		path := materials[i].DiffuseMap
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		texture, err := loadImage(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("material %s: %w", materials[i].Name, err)
+		}
+		materials[i].Texture = texture
+	}
+	return materials, nil
+}
+
+func loadImage(filename string) (image.Image, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", filename, err)
+	}
+	return img, nil
+}
+
+// ParseMTL reads materials from r without loading textures; name is used
+// in error messages.
+func ParseMTL(r io.Reader, name string) ([]Material, error) {
+	var materials []Material
+	var current *Material
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		keyword, args := fields[0], fields[1:]
+		if keyword == "newmtl" {
+			if len(args) < 1 {
+				return nil, &ParseError{File: name, Line: lineNo, Err: errors.New("newmtl needs a name")}
+			}
+			materials = append(materials, DefaultMaterial())
+			current = &materials[len(materials)-1]
+			current.Name = strings.Join(args, " ")
+			current.Diffuse = linalg.Vec3{X: 0.8, Y: 0.8, Z: 0.8}
+			continue
+		}
+		if current == nil {
+			if keyword == "Ka" || keyword == "Kd" || keyword == "Ks" || keyword == "Ns" || keyword == "d" || keyword == "Tr" || keyword == "map_Kd" {
+				return nil, &ParseError{File: name, Line: lineNo, Err: fmt.Errorf("%s before newmtl", keyword)}
+			}
+			continue
+		}
+
+		if err := parseMaterialStatement(current, keyword, args); err != nil {
+			return nil, &ParseError{File: name, Line: lineNo, Err: err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return materials, nil
+}
+
+func parseMaterialStatement(m *Material, keyword string, args []string) error {
+	var err error
+	switch keyword {
+	case "Ka":
+		m.Ambient, err = parseColor(args)
+	case "Kd":
+		m.Diffuse, err = parseColor(args)
+	case "Ks":
+		m.Specular, err = parseColor(args)
+	case "Ns":
+		m.Shininess, err = parseScalar(args)
+	case "d":
+		m.Dissolve, err = parseScalar(args)
+	case "Tr":
+		var tr float64
+		tr, err = parseScalar(args)
+		m.Dissolve = 1 - tr
+	case "map_Kd":
+		if len(args) < 1 {
+			return errors.New("map_Kd needs a file name")
+		}
+		// Options such as -s or -o precede the file name, which comes last.
+		m.DiffuseMap = args[len(args)-1]
+	}
+	return err
+}
+
+// parseColor reads "r g b" or a single gray value; "spectral" and "xyz"
+// forms are not supported.
+func parseColor(args []string) (linalg.Vec3, error) {
+	if len(args) == 0 {
+		return linalg.Vec3{}, errors.New("color needs 1 or 3 values")
+	}
+	if args[0] == "spectral" || args[0] == "xyz" {
+		return linalg.Vec3{}, fmt.Errorf("%s colors are not supported", args[0])
+	}
+	if len(args) < 3 {
+		gray, err := parseScalar(args)
+		return linalg.Vec3{X: gray, Y: gray, Z: gray}, err
+	}
+	return parseVec3(args)
+}
+
+func parseScalar(args []string) (float64, error) {
+	if len(args) < 1 {
+		return 0, errors.New("missing value")
+	}
+	// "d -halo 0.5" puts an option before the value.
+	value, err := strconv.ParseFloat(args[len(args)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", args[len(args)-1])
+	}
+	return value, nil
+}
@@ -0,0 +1,275 @@
+package mesh
+
+/**
+ * Wavefront OBJ reader.
+ *
+ * Supported statements:
+ * - v, vn, vt          positions, normals and texture coordinates
+ * - f                  triangles, quads and n-gons in any of the v, v/vt,
+ *                      v//vn and v/vt/vn forms, with negative (relative)
+ *                      indices; polygons are triangulated
+ * - o, g, usemtl       start a new Group of faces
+ * - s                  smoothing group ("off" and 0 shade faces flat);
+ *                      faces before any s statement are in group 1, so
+ *                      that their vertex normals are used
+ * - mtllib             recorded in Mesh.MaterialLibs and, when loading from
+ *                      disk, read from the OBJ's directory (see LoadMTL)
+ *
+ * Other statements (curves, lines, points, ...) are skipped. Malformed
+ * statements are reported as a *ParseError carrying the line number.
+ */
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"zontengine/internal/linalg"
+)
+
+func LoadOBJ(filename string) (*Mesh, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	m, err := ParseOBJ(file, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// A library that is referenced but missing is common in exported
+	// assets; its faces simply keep the default material.
+	var materials []Material
+	for _, lib := range m.MaterialLibs {
+		path := filepath.Join(filepath.Dir(filename), lib)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		libMaterials, err := LoadMTL(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading material library %s: %w", lib, err)
+		}
+		materials = append(materials, libMaterials...)
+	}
+	m.SetMaterials(materials)
+
+	return m, nil
+}
+
+// ParseOBJ reads an OBJ model from r; name is used in error messages.
+func ParseOBJ(r io.Reader, name string) (*Mesh, error) {
+	p := objParser{
+		mesh:   &Mesh{Name: name},
+		smooth: 1,
+		group:  -1,
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+
+		// Backslash continues a statement on the next line.
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			lineNo++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(scanner.Text())
+		}
+
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := p.statement(fields[0], fields[1:]); err != nil {
+			return nil, &ParseError{File: name, Line: lineNo, Err: err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	return p.mesh, nil
+}
+
+type objParser struct {
+	mesh *Mesh
+
+	object   string
+	name     string
+	material string
+	smooth   int
+	group    int
+}
+
+func (p *objParser) statement(keyword string, args []string) error {
+	switch keyword {
+	case "v":
+		v, err := parseVec3(args)
+		if err != nil {
+			return err
+		}
+		p.mesh.Positions = append(p.mesh.Positions, v)
+	case "vn":
+		v, err := parseVec3(args)
+		if err != nil {
+			return err
+		}
+		p.mesh.Normals = append(p.mesh.Normals, v.Normalize())
+	case "vt":
+		if len(args) < 1 {
+			return errors.New("vt needs at least 1 coordinate")
+		}
+		u, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return fmt.Errorf("bad texture coordinate %q", args[0])
+		}
+		v := 0.0
+		if len(args) > 1 {
+			if v, err = strconv.ParseFloat(args[1], 64); err != nil {
+				return fmt.Errorf("bad texture coordinate %q", args[1])
+			}
+		}
+		p.mesh.UVs = append(p.mesh.UVs, linalg.Vec2{X: u, Y: v})
+	case "f":
+		return p.face(args)
+	case "o":
+		p.object = strings.Join(args, " ")
+		p.group = -1
+	case "g":
+		p.name = strings.Join(args, " ")
+		p.group = -1
+	case "usemtl":
+		if len(args) < 1 {
+			return errors.New("usemtl needs a material name")
+		}
+		p.material = strings.Join(args, " ")
+		p.group = -1
+	case "s":
+		if len(args) < 1 {
+			return errors.New("s needs a group number or off")
+		}
+		if args[0] == "off" {
+			p.smooth = 0
+			return nil
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("bad smoothing group %q", args[0])
+		}
+		p.smooth = n
+	case "mtllib":
+		if len(args) < 1 {
+			return errors.New("mtllib needs a file name")
+		}
+		p.mesh.MaterialLibs = append(p.mesh.MaterialLibs, args...)
+	}
+	return nil
+}
+
+func (p *objParser) face(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face needs at least 3 vertices, got %d", len(args))
+	}
+
+	positions := make([]int, len(args))
+	normals := make([]int, len(args))
+	uvs := make([]int, len(args))
+	corners := make([]linalg.Vec3, len(args))
+
+	for i, arg := range args {
+		parts := strings.Split(arg, "/")
+		if len(parts) > 3 {
+			return fmt.Errorf("bad face vertex %q", arg)
+		}
+
+		var err error
+		if positions[i], err = resolveIndex(parts[0], len(p.mesh.Positions)); err != nil {
+			return fmt.Errorf("face vertex %q: %w", arg, err)
+		}
+		uvs[i], normals[i] = -1, -1
+		if len(parts) > 1 && parts[1] != "" {
+			if uvs[i], err = resolveIndex(parts[1], len(p.mesh.UVs)); err != nil {
+				return fmt.Errorf("face texture %q: %w", arg, err)
+			}
+		}
+		if len(parts) > 2 && parts[2] != "" {
+			if normals[i], err = resolveIndex(parts[2], len(p.mesh.Normals)); err != nil {
+				return fmt.Errorf("face normal %q: %w", arg, err)
+			}
+		}
+		corners[i] = p.mesh.Positions[positions[i]]
+	}
+
+	group := p.currentGroup()
+	for _, t := range Triangulate(corners) {
+		p.mesh.Faces = append(p.mesh.Faces, Face{
+			Positions: [3]int{positions[t[0]], positions[t[1]], positions[t[2]]},
+			Normals:   [3]int{normals[t[0]], normals[t[1]], normals[t[2]]},
+			UVs:       [3]int{uvs[t[0]], uvs[t[1]], uvs[t[2]]},
+			Group:     group,
+			Smooth:    p.smooth,
+			Material:  -1,
+		})
+	}
+	return nil
+}
+
+// currentGroup returns the index of the group for the current o/g/usemtl
+// state, appending a new one after any of them changed.
+func (p *objParser) currentGroup() int {
+	if p.group < 0 {
+		p.mesh.Groups = append(p.mesh.Groups, Group{Object: p.object, Name: p.name, Material: p.material})
+		p.group = len(p.mesh.Groups) - 1
+	}
+	return p.group
+}
+
+// resolveIndex converts a 1-based or negative relative OBJ index to a
+// 0-based index into an array of length count.
+func resolveIndex(s string, count int) (int, error) {
+	index, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad index %q", s)
+	}
+	switch {
+	case index > 0:
+		index--
+	case index < 0:
+		index += count
+	default:
+		return 0, errors.New("index 0 is not valid")
+	}
+	if index < 0 || index >= count {
+		return 0, fmt.Errorf("index %s out of range (have %d)", s, count)
+	}
+	return index, nil
+}
+
+func parseVec3(args []string) (linalg.Vec3, error) {
+	if len(args) < 3 {
+		return linalg.Vec3{}, fmt.Errorf("need 3 coordinates, got %d", len(args))
+	}
+	var xyz [3]float64
+	for i := 0; i < 3; i++ {
+		f, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return linalg.Vec3{}, fmt.Errorf("bad coordinate %q", args[i])
+		}
+		xyz[i] = f
+	}
+	return linalg.Vec3{X: xyz[0], Y: xyz[1], Z: xyz[2]}, nil
+}
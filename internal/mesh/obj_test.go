@@ -0,0 +1,132 @@
+package mesh
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"zontengine/internal/linalg"
+)
+
+// area returns the total area of the faces of m.
+func area(m *Mesh) float64 {
+	total := 0.0
+	for _, face := range m.Faces {
+		a, b, c := m.Positions[face.Positions[0]], m.Positions[face.Positions[1]], m.Positions[face.Positions[2]]
+		total += b.Sub(a).Cross(c.Sub(a)).Len() / 2
+	}
+	return total
+}
+
+func TestParseOBJPolygons(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		triangles int
+		area      float64
+	}{
+		{
+			name:      "triangle",
+			source:    "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n",
+			triangles: 1,
+			area:      0.5,
+		},
+		{
+			name:      "quad",
+			source:    "v 0 0 0\nv 2 0 0\nv 2 1 0\nv 0 1 0\nf 1 2 3 4\n",
+			triangles: 2,
+			area:      2,
+		},
+		{
+			name:      "hexagon with relative indices",
+			source:    "v 2 0 0\nv 1 1.732 0\nv -1 1.732 0\nv -2 0 0\nv -1 -1.732 0\nv 1 -1.732 0\nf -6 -5 -4 -3 -2 -1\n",
+			triangles: 4,
+			area:      6 * 1.732,
+		},
+		{
+			name: "concave pentagon",
+			// An arrow head: fanning from the first corner would cover
+			// area outside the notch.
+			source:    "v 0 0 0\nv 2 1 0\nv 0 2 0\nv 4 2 0\nv 4 0 0\nf 3 2 1 5 4\n",
+			triangles: 3,
+			area:      6,
+		},
+		{
+			name:      "corners with texture coordinates and normals",
+			source:    "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nvt 0 0\nvn 0 0 1\nf 1/1/1 2/1/1 3/1/1 4/1/1\n",
+			triangles: 2,
+			area:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseOBJ(strings.NewReader(tt.source), "test.obj")
+			if err != nil {
+				t.Fatalf("ParseOBJ: %v", err)
+			}
+			if len(m.Faces) != tt.triangles {
+				t.Errorf("got %d triangles, want %d", len(m.Faces), tt.triangles)
+			}
+			if got := area(m); math.Abs(got-tt.area) > 1e-9 {
+				t.Errorf("triangles cover %v, want %v", got, tt.area)
+			}
+
+			// Triangulation keeps the winding of the polygon.
+			for i, face := range m.Faces {
+				a, b, c := m.Positions[face.Positions[0]], m.Positions[face.Positions[1]], m.Positions[face.Positions[2]]
+				if b.Sub(a).Cross(c.Sub(a)).Dot(linalg.Vec3{Z: 1}) <= 0 {
+					t.Errorf("triangle %d is turned around", i)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOBJErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		line   int
+	}{
+		{"bad v", "v 0 0 0\nv 1 0 0\nv 0 x 0\nf 1 2 3\n", 3},
+		{"short v", "# comment\n\nv 0 0\n", 3},
+		{"index out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n", 4},
+		{"too few corners", "v 0 0 0\nv 1 0 0\nf 1 2\n", 3},
+		{"after a continued line", "v 0 0 \\\n0\nv 1 1\n", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseOBJ(strings.NewReader(tt.source), "test.obj")
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParseOBJ = %v, want a *ParseError", err)
+			}
+			if parseErr.File != "test.obj" || parseErr.Line != tt.line {
+				t.Errorf("error at %s:%d, want test.obj:%d (%v)", parseErr.File, parseErr.Line, tt.line, err)
+			}
+		})
+	}
+}
+
+func TestParseOBJSmoothing(t *testing.T) {
+	const triangle = "f 1//1 2//1 3//1\n"
+	source := "v 0 0 0\nv 1 0 0\nv 0 1 0\nvn 0 0 1\n" +
+		triangle + "s off\n" + triangle + "s 2\n" + triangle + "s 0\n" + triangle
+
+	m, err := ParseOBJ(strings.NewReader(source), "test.obj")
+	if err != nil {
+		t.Fatalf("ParseOBJ: %v", err)
+	}
+	want := []int{1, 0, 2, 0}
+	if len(m.Faces) != len(want) {
+		t.Fatalf("got %d faces, want %d", len(m.Faces), len(want))
+	}
+	for i, face := range m.Faces {
+		if face.Smooth != want[i] {
+			t.Errorf("face %d is in smoothing group %d, want %d", i, face.Smooth, want[i])
+		}
+	}
+}
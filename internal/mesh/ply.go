@@ -0,0 +1,517 @@
+package mesh
+
+/**
+ * Stanford PLY reader for the ascii, binary_little_endian and
+ * binary_big_endian formats.
+ *
+ * Supported elements and properties:
+ * - vertex  x, y, z; optional nx, ny, nz normals and red, green, blue
+ *           colors (diffuse_red, ... too), stored in Mesh.Normals and
+ *           Mesh.Colors at the index of their position
+ * - face    vertex_indices (or vertex_index), a list of any length; polygons
+ *           are triangulated
+ *
+ * Other elements and properties are read and skipped. Integer colors are
+ * scaled by the largest value of their type (255 for uchar), floating point
+ * colors are taken as 0..1. A file without faces is a point cloud: its mesh
+ * has positions but no faces, which the renderer draws as points.
+ */
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"zontengine/internal/linalg"
+)
+
+func LoadPLY(filename string) (*Mesh, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParsePLY(file, filename)
+}
+
+// ParsePLY reads a PLY model from r; name is used in error messages.
+func ParsePLY(r io.Reader, name string) (*Mesh, error) {
+	reader := bufio.NewReader(r)
+	header, err := readPLYHeader(reader, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var values plyValues
+	switch header.format {
+	case "ascii":
+		values = &asciiValues{r: reader, lineNo: header.lines + 1}
+	case "binary_little_endian":
+		values = &binaryValues{r: reader, order: binary.LittleEndian}
+	case "binary_big_endian":
+		values = &binaryValues{r: reader, order: binary.BigEndian}
+	}
+
+	p := plyParser{mesh: &Mesh{Name: name}}
+	for _, element := range header.elements {
+		if err := p.element(element, values); err != nil {
+			if line := values.line(); line > 0 {
+				return nil, &ParseError{File: name, Line: line, Err: err}
+			}
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if len(p.mesh.Positions) == 0 {
+		return nil, fmt.Errorf("%s: no vertices found", name)
+	}
+	return p.mesh, nil
+}
+
+// plyType is the type of a property value.
+type plyType int
+
+const (
+	plyInt8 plyType = iota
+	plyUint8
+	plyInt16
+	plyUint16
+	plyInt32
+	plyUint32
+	plyFloat32
+	plyFloat64
+)
+
+var plyTypes = map[string]plyType{
+	"char": plyInt8, "int8": plyInt8,
+	"uchar": plyUint8, "uint8": plyUint8,
+	"short": plyInt16, "int16": plyInt16,
+	"ushort": plyUint16, "uint16": plyUint16,
+	"int": plyInt32, "int32": plyInt32,
+	"uint": plyUint32, "uint32": plyUint32,
+	"float": plyFloat32, "float32": plyFloat32,
+	"double": plyFloat64, "float64": plyFloat64,
+}
+
+func (t plyType) size() int {
+	switch t {
+	case plyInt8, plyUint8:
+		return 1
+	case plyInt16, plyUint16:
+		return 2
+	case plyFloat64:
+		return 8
+	}
+	return 4
+}
+
+// colorScale returns the value of full intensity for colors of this type.
+func (t plyType) colorScale() float64 {
+	switch t {
+	case plyInt8:
+		return math.MaxInt8
+	case plyUint8:
+		return math.MaxUint8
+	case plyInt16:
+		return math.MaxInt16
+	case plyUint16:
+		return math.MaxUint16
+	case plyInt32:
+		return math.MaxInt32
+	case plyUint32:
+		return math.MaxUint32
+	}
+	return 1
+}
+
+type plyProperty struct {
+	name string
+	kind plyType
+	// list properties hold a count of type countKind followed by that many
+	// values of type kind.
+	list      bool
+	countKind plyType
+}
+
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+type plyHeader struct {
+	format   string
+	elements []plyElement
+	// lines is the number of lines up to and including end_header.
+	lines int
+}
+
+func readPLYHeader(r *bufio.Reader, name string) (*plyHeader, error) {
+	header := &plyHeader{}
+	for lineNo := 1; ; lineNo++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = errors.New("header ends without end_header")
+			}
+			return nil, &ParseError{File: name, Line: lineNo, Err: err}
+		}
+		fields := strings.Fields(line)
+
+		if lineNo == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return nil, &ParseError{File: name, Line: lineNo, Err: errors.New("not a PLY file")}
+			}
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "end_header" {
+			if header.format == "" {
+				return nil, &ParseError{File: name, Line: lineNo, Err: errors.New("missing format")}
+			}
+			header.lines = lineNo
+			return header, nil
+		}
+		if err := header.statement(fields[0], fields[1:]); err != nil {
+			return nil, &ParseError{File: name, Line: lineNo, Err: err}
+		}
+	}
+}
+
+func (h *plyHeader) statement(keyword string, args []string) error {
+	switch keyword {
+	case "format":
+		if len(args) < 1 {
+			return errors.New("format needs a name")
+		}
+		switch args[0] {
+		case "ascii", "binary_little_endian", "binary_big_endian":
+			h.format = args[0]
+		default:
+			return fmt.Errorf("unknown format %q", args[0])
+		}
+	case "element":
+		if len(args) != 2 {
+			return errors.New("element needs a name and a count")
+		}
+		count, err := strconv.Atoi(args[1])
+		if err != nil || count < 0 {
+			return fmt.Errorf("bad element count %q", args[1])
+		}
+		h.elements = append(h.elements, plyElement{name: args[0], count: count})
+	case "property":
+		if len(h.elements) == 0 {
+			return errors.New("property before any element")
+		}
+		property, err := parsePLYProperty(args)
+		if err != nil {
+			return err
+		}
+		element := &h.elements[len(h.elements)-1]
+		element.properties = append(element.properties, property)
+	case "comment", "obj_info":
+	default:
+		return fmt.Errorf("unknown keyword %q", keyword)
+	}
+	return nil
+}
+
+func parsePLYProperty(args []string) (plyProperty, error) {
+	lookup := func(name string) (plyType, error) {
+		if kind, exists := plyTypes[name]; exists {
+			return kind, nil
+		}
+		return 0, fmt.Errorf("unknown property type %q", name)
+	}
+
+	if len(args) == 4 && args[0] == "list" {
+		countKind, err := lookup(args[1])
+		if err != nil {
+			return plyProperty{}, err
+		}
+		kind, err := lookup(args[2])
+		if err != nil {
+			return plyProperty{}, err
+		}
+		return plyProperty{name: args[3], kind: kind, list: true, countKind: countKind}, nil
+	}
+	if len(args) != 2 {
+		return plyProperty{}, errors.New("property needs a type and a name")
+	}
+	kind, err := lookup(args[0])
+	if err != nil {
+		return plyProperty{}, err
+	}
+	return plyProperty{name: args[1], kind: kind}, nil
+}
+
+// plyValues reads the values of the body one at a time.
+type plyValues interface {
+	read(kind plyType) (float64, error)
+	// line returns the line of the value read last, or 0 for binary data.
+	line() int
+}
+
+type asciiValues struct {
+	r     *bufio.Reader
+	token []byte
+	// lineNo is the line being read, tokenLine the one the last value
+	// started on.
+	lineNo    int
+	tokenLine int
+}
+
+func (a *asciiValues) line() int {
+	return a.tokenLine
+}
+
+func (a *asciiValues) read(kind plyType) (float64, error) {
+	a.token = a.token[:0]
+	for {
+		c, err := a.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(a.token) > 0 {
+				break
+			}
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if c == '\n' {
+			a.lineNo++
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			if len(a.token) > 0 {
+				break
+			}
+			continue
+		}
+		if len(a.token) == 0 {
+			a.tokenLine = a.lineNo
+		}
+		a.token = append(a.token, c)
+	}
+	value, err := strconv.ParseFloat(string(a.token), 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", a.token)
+	}
+	return value, nil
+}
+
+type binaryValues struct {
+	r      io.Reader
+	order  binary.ByteOrder
+	buffer [8]byte
+}
+
+func (b *binaryValues) line() int {
+	return 0
+}
+
+func (b *binaryValues) read(kind plyType) (float64, error) {
+	data := b.buffer[:kind.size()]
+	if _, err := io.ReadFull(b.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	switch kind {
+	case plyInt8:
+		return float64(int8(data[0])), nil
+	case plyUint8:
+		return float64(data[0]), nil
+	case plyInt16:
+		return float64(int16(b.order.Uint16(data))), nil
+	case plyUint16:
+		return float64(b.order.Uint16(data)), nil
+	case plyInt32:
+		return float64(int32(b.order.Uint32(data))), nil
+	case plyUint32:
+		return float64(b.order.Uint32(data)), nil
+	case plyFloat32:
+		return float64(math.Float32frombits(b.order.Uint32(data))), nil
+	}
+	return math.Float64frombits(b.order.Uint64(data)), nil
+}
+
+type plyParser struct {
+	mesh *Mesh
+}
+
+func (p *plyParser) element(element plyElement, values plyValues) error {
+	// Scalar properties land in record by position; the face index list,
+	// the only list used, in indices.
+	record := make([]float64, len(element.properties))
+	var indices []float64
+	listIndex := -1
+	if element.name == "face" {
+		listIndex = findPLYProperty(element.properties, "vertex_indices", "vertex_index")
+	}
+
+	var vertex *plyVertexLayout
+	if element.name == "vertex" {
+		layout, err := newPLYVertexLayout(element.properties)
+		if err != nil {
+			return err
+		}
+		vertex = &layout
+	}
+
+	for i := 0; i < element.count; i++ {
+		for j, property := range element.properties {
+			if !property.list {
+				value, err := values.read(property.kind)
+				if err != nil {
+					return fmt.Errorf("%s %d: %w", element.name, i, err)
+				}
+				record[j] = value
+				continue
+			}
+
+			count, err := values.read(property.countKind)
+			if err != nil {
+				return fmt.Errorf("%s %d: %w", element.name, i, err)
+			}
+			if count < 0 || count != math.Trunc(count) || math.IsInf(count, 0) {
+				return fmt.Errorf("%s %d: bad list length %v", element.name, i, count)
+			}
+			if j == listIndex {
+				indices = indices[:0]
+			}
+			for k := 0; k < int(count); k++ {
+				value, err := values.read(property.kind)
+				if err != nil {
+					return fmt.Errorf("%s %d: %w", element.name, i, err)
+				}
+				if j == listIndex {
+					indices = append(indices, value)
+				}
+			}
+		}
+
+		switch {
+		case vertex != nil:
+			vertex.add(p.mesh, record)
+		case listIndex >= 0:
+			if err := p.face(indices); err != nil {
+				return fmt.Errorf("face %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *plyParser) face(indices []float64) error {
+	if len(indices) < 3 {
+		return fmt.Errorf("needs at least 3 vertices, got %d", len(indices))
+	}
+
+	positions := make([]int, len(indices))
+	corners := make([]linalg.Vec3, len(indices))
+	for i, index := range indices {
+		// NaN and infinities fail the first test; the range is checked
+		// before converting, which is undefined for such values.
+		if index != math.Trunc(index) || math.IsInf(index, 0) {
+			return fmt.Errorf("vertex index %v is not a whole number", index)
+		}
+		if index < 0 || index >= float64(len(p.mesh.Positions)) {
+			return fmt.Errorf("vertex index %v out of range (have %d)", index, len(p.mesh.Positions))
+		}
+		positions[i] = int(index)
+		corners[i] = p.mesh.Positions[positions[i]]
+	}
+
+	if len(p.mesh.Groups) == 0 {
+		p.mesh.Groups = append(p.mesh.Groups, Group{})
+	}
+	hasNormals := len(p.mesh.Normals) > 0
+	for _, t := range Triangulate(corners) {
+		face := Face{
+			Positions: [3]int{positions[t[0]], positions[t[1]], positions[t[2]]},
+			Normals:   [3]int{-1, -1, -1},
+			UVs:       [3]int{-1, -1, -1},
+			Material:  -1,
+		}
+		if hasNormals {
+			face.Normals = face.Positions
+			face.Smooth = 1
+		}
+		p.mesh.Faces = append(p.mesh.Faces, face)
+	}
+	return nil
+}
+
+// plyVertexLayout locates the used properties in a vertex record; -1 marks
+// an absent one.
+type plyVertexLayout struct {
+	position [3]int
+	normal   [3]int
+	color    [3]int
+	scale    [3]float64
+}
+
+func newPLYVertexLayout(properties []plyProperty) (plyVertexLayout, error) {
+	var layout plyVertexLayout
+	for axis, name := range [3]string{"x", "y", "z"} {
+		layout.position[axis] = findPLYProperty(properties, name)
+		if layout.position[axis] < 0 {
+			return layout, fmt.Errorf("vertex has no %s property", name)
+		}
+		layout.normal[axis] = findPLYProperty(properties, "n"+name)
+	}
+	for channel, name := range [3]string{"red", "green", "blue"} {
+		layout.color[channel] = findPLYProperty(properties, name, "diffuse_"+name)
+		if layout.color[channel] >= 0 {
+			layout.scale[channel] = properties[layout.color[channel]].kind.colorScale()
+		}
+	}
+	// Attributes missing a component are ignored as a whole.
+	if layout.normal[0] < 0 || layout.normal[1] < 0 || layout.normal[2] < 0 {
+		layout.normal = [3]int{-1, -1, -1}
+	}
+	if layout.color[0] < 0 || layout.color[1] < 0 || layout.color[2] < 0 {
+		layout.color = [3]int{-1, -1, -1}
+	}
+	return layout, nil
+}
+
+func (l plyVertexLayout) add(m *Mesh, record []float64) {
+	m.Positions = append(m.Positions, linalg.Vec3{
+		X: record[l.position[0]], Y: record[l.position[1]], Z: record[l.position[2]],
+	})
+	if l.normal[0] >= 0 {
+		m.Normals = append(m.Normals, linalg.Vec3{
+			X: record[l.normal[0]], Y: record[l.normal[1]], Z: record[l.normal[2]],
+		}.Normalize())
+	}
+	if l.color[0] >= 0 {
+		m.Colors = append(m.Colors, linalg.Vec3{
+			X: record[l.color[0]] / l.scale[0],
+			Y: record[l.color[1]] / l.scale[1],
+			Z: record[l.color[2]] / l.scale[2],
+		})
+	}
+}
+
+// findPLYProperty returns the index of the first property with one of the
+// names, or -1.
+func findPLYProperty(properties []plyProperty, names ...string) int {
+	for _, name := range names {
+		for i, property := range properties {
+			if property.name == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,151 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const plyTriangleHeader = `ply
+format ascii 1.0
+comment three corners and one face
+element vertex 3
+property float x
+property float y
+property float z
+element face 1
+property list uchar int vertex_indices
+end_header
+0 0 0
+1 0 0
+0 1 0
+`
+
+func TestParsePLY(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		positions int
+		triangles int
+		normals   int
+		colors    int
+	}{
+		{
+			name:      "triangle",
+			source:    plyTriangleHeader + "3 0 1 2\n",
+			positions: 3, triangles: 1,
+		},
+		{
+			name: "quad with normals and colors",
+			source: `ply
+format ascii 1.0
+element vertex 4
+property float x
+property float y
+property float z
+property float nx
+property float ny
+property float nz
+property uchar red
+property uchar green
+property uchar blue
+element face 1
+property list uchar int vertex_indices
+end_header
+0 0 0 0 0 1 255 0 0
+1 0 0 0 0 1 0 255 0
+1 1 0 0 0 1 0 0 255
+0 1 0 0 0 1 255 255 255
+4 0 1 2 3
+`,
+			positions: 4, triangles: 2, normals: 4, colors: 4,
+		},
+		{
+			name: "point cloud",
+			source: `ply
+format ascii 1.0
+element vertex 2
+property double x
+property double y
+property double z
+end_header
+0 0 0
+1 2 3
+`,
+			positions: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParsePLY(strings.NewReader(tt.source), "test.ply")
+			if err != nil {
+				t.Fatalf("ParsePLY: %v", err)
+			}
+			if len(m.Positions) != tt.positions || len(m.Faces) != tt.triangles {
+				t.Errorf("got %d triangles over %d positions, want %d over %d",
+					len(m.Faces), len(m.Positions), tt.triangles, tt.positions)
+			}
+			if len(m.Normals) != tt.normals || len(m.Colors) != tt.colors {
+				t.Errorf("got %d normals and %d colors, want %d and %d",
+					len(m.Normals), len(m.Colors), tt.normals, tt.colors)
+			}
+		})
+	}
+}
+
+func TestParsePLYBinary(t *testing.T) {
+	var body bytes.Buffer
+	for _, v := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		binary.Write(&body, binary.BigEndian, v)
+	}
+	body.WriteByte(3)
+	for _, index := range []int32{0, 1, 2} {
+		binary.Write(&body, binary.BigEndian, index)
+	}
+	header := strings.Replace(plyTriangleHeader, "ascii", "binary_big_endian", 1)
+	header = header[:strings.Index(header, "end_header\n")+len("end_header\n")]
+
+	m, err := ParsePLY(strings.NewReader(header+body.String()), "test.ply")
+	if err != nil {
+		t.Fatalf("ParsePLY: %v", err)
+	}
+	if len(m.Positions) != 3 || len(m.Faces) != 1 || m.Positions[1].X != 1 {
+		t.Errorf("got %d triangles over positions %v", len(m.Faces), m.Positions)
+	}
+}
+
+func TestParsePLYBadFaces(t *testing.T) {
+	tests := []struct {
+		name string
+		face string
+		want string
+	}{
+		{"index past the end", "3 0 1 3", "vertex index 3 out of range"},
+		{"negative index", "3 -1 1 2", "vertex index -1 out of range"},
+		{"NaN index", "3 0 nan 2", "vertex index NaN is not a whole number"},
+		{"infinite index", "3 0 1 inf", "vertex index +Inf is not a whole number"},
+		{"fractional index", "3 0 1.5 2", "vertex index 1.5 is not a whole number"},
+		{"too few corners", "2 0 1", "needs at least 3 vertices"},
+		{"fractional length", "2.5 0 1 2", "bad list length 2.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePLY(strings.NewReader(plyTriangleHeader+tt.face+"\n"), "test.ply")
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParsePLY = %v, want a *ParseError", err)
+			}
+			// The header takes 10 lines and the vertices 3.
+			if parseErr.Line != 14 {
+				t.Errorf("error on line %d, want 14 (%v)", parseErr.Line, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParsePLY = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
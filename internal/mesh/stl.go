@@ -0,0 +1,254 @@
+package mesh
+
+/**
+ * STL reader for both the binary and the ASCII variant.
+ *
+ * - Binary: an 80 byte header, a little-endian triangle count and 50 bytes
+ *   per triangle (normal, three vertices, attribute word)
+ * - ASCII:  "solid name", then "facet normal nx ny nz", "outer loop",
+ *           three "vertex x y z", "endloop" and "endfacet" per triangle,
+ *           ended by "endsolid"; a file may hold several solids
+ *
+ * A file is binary when its size matches the triangle count in its header,
+ * or exceeds it (some exporters pad the file) and the file is not ASCII STL
+ * text. Headers starting with "solid" are common in binary files too, so
+ * that word alone decides nothing.
+ *
+ * STL repeats every vertex in each facet; identical positions are merged so
+ * the mesh is indexed like any other. Facet normals are kept as the normals
+ * of all three corners, which shades each facet flat, and a facet whose
+ * vertex order disagrees with its normal is turned around. Facets without a
+ * usable normal (all zero is common) get the one of their vertex order.
+ * Every solid becomes a Group; binary files hold one, named after the
+ * header.
+ */
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"zontengine/internal/linalg"
+)
+
+const (
+	stlHeaderSize   = 80
+	stlTriangleSize = 50
+)
+
+func LoadSTL(filename string) (*Mesh, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseSTL(file, filename)
+}
+
+// ParseSTL reads a binary or ASCII STL model from r; name is used in error
+// messages.
+func ParseSTL(r io.Reader, name string) (*Mesh, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	if isBinarySTL(data) {
+		return parseBinarySTL(data, name)
+	}
+	return parseASCIISTL(data, name)
+}
+
+// isBinarySTL reports whether data holds the binary STL its header
+// announces: exactly, or followed by padding when data is not ASCII STL.
+func isBinarySTL(data []byte) bool {
+	size, ok := binarySTLSize(data)
+	if !ok || size > int64(len(data)) {
+		return false
+	}
+	return size == int64(len(data)) || !isASCIISTL(data)
+}
+
+// binarySTLSize returns the file size announced by the header of a binary
+// STL starting with head, if head is long enough to hold it.
+func binarySTLSize(head []byte) (int64, bool) {
+	if len(head) < stlHeaderSize+4 {
+		return 0, false
+	}
+	count := binary.LittleEndian.Uint32(head[stlHeaderSize:])
+	return stlHeaderSize + 4 + int64(count)*stlTriangleSize, true
+}
+
+// looksLikeASCIISTL reports whether head starts like an ASCII STL file.
+func looksLikeASCIISTL(head []byte) bool {
+	fields := bytes.Fields(head)
+	return len(fields) > 0 && string(fields[0]) == "solid"
+}
+
+// isASCIISTL reports whether data starts like an ASCII STL file and holds
+// nothing but text.
+func isASCIISTL(data []byte) bool {
+	if !looksLikeASCIISTL(data) {
+		return false
+	}
+	for _, c := range data {
+		if c >= 0x80 || c < ' ' && c != '\n' && c != '\r' && c != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseBinarySTL(data []byte, name string) (*Mesh, error) {
+	header := strings.TrimSpace(strings.TrimRight(string(data[:stlHeaderSize]), "\x00"))
+	count := int(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+
+	b := newSTLBuilder(name)
+	b.solid(strings.TrimSpace(strings.TrimPrefix(header, "solid")))
+	for i := 0; i < count; i++ {
+		offset := stlHeaderSize + 4 + i*stlTriangleSize
+		var values [12]float64
+		for j := range values {
+			bits := binary.LittleEndian.Uint32(data[offset+j*4:])
+			values[j] = float64(math.Float32frombits(bits))
+		}
+		b.facet(
+			linalg.Vec3{X: values[0], Y: values[1], Z: values[2]},
+			[3]linalg.Vec3{
+				{X: values[3], Y: values[4], Z: values[5]},
+				{X: values[6], Y: values[7], Z: values[8]},
+				{X: values[9], Y: values[10], Z: values[11]},
+			},
+		)
+	}
+	return b.mesh, nil
+}
+
+func parseASCIISTL(data []byte, name string) (*Mesh, error) {
+	b := newSTLBuilder(name)
+
+	var normal linalg.Vec3
+	var corners []linalg.Vec3
+	inFacet := false
+
+	statement := func(keyword string, args []string) error {
+		switch keyword {
+		case "solid":
+			b.solid(strings.Join(args, " "))
+		case "facet":
+			if len(args) < 1 || args[0] != "normal" {
+				return errors.New("facet needs a normal")
+			}
+			n, err := parseVec3(args[1:])
+			if err != nil {
+				return fmt.Errorf("facet normal: %w", err)
+			}
+			normal, corners, inFacet = n, corners[:0], true
+		case "vertex":
+			if !inFacet {
+				return errors.New("vertex outside a facet")
+			}
+			v, err := parseVec3(args)
+			if err != nil {
+				return fmt.Errorf("vertex: %w", err)
+			}
+			corners = append(corners, v)
+		case "endfacet":
+			if len(corners) != 3 {
+				return fmt.Errorf("facet needs 3 vertices, got %d", len(corners))
+			}
+			b.facet(normal, [3]linalg.Vec3{corners[0], corners[1], corners[2]})
+			inFacet = false
+		case "outer", "endloop", "endsolid":
+		default:
+			return fmt.Errorf("unknown keyword %q", keyword)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if err := statement(fields[0], fields[1:]); err != nil {
+			return nil, &ParseError{File: name, Line: lineNo, Err: err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	if len(b.mesh.Faces) == 0 {
+		return nil, fmt.Errorf("%s: no facets found", name)
+	}
+	return b.mesh, nil
+}
+
+// stlBuilder collects facets into an indexed mesh.
+type stlBuilder struct {
+	mesh      *Mesh
+	positions map[linalg.Vec3]int
+	normals   map[linalg.Vec3]int
+	group     int
+}
+
+func newSTLBuilder(name string) *stlBuilder {
+	return &stlBuilder{
+		mesh:      &Mesh{Name: name},
+		positions: make(map[linalg.Vec3]int),
+		normals:   make(map[linalg.Vec3]int),
+		group:     -1,
+	}
+}
+
+// solid starts a new group for the facets that follow.
+func (b *stlBuilder) solid(name string) {
+	b.mesh.Groups = append(b.mesh.Groups, Group{Object: name})
+	b.group = len(b.mesh.Groups) - 1
+}
+
+func (b *stlBuilder) facet(normal linalg.Vec3, corners [3]linalg.Vec3) {
+	if b.group < 0 {
+		b.solid("")
+	}
+
+	winding := corners[1].Sub(corners[0]).Cross(corners[2].Sub(corners[0]))
+	if winding.Len() == 0 {
+		// A degenerate facet covers no area; there is nothing to draw.
+		return
+	}
+	// The negation also catches NaN normals.
+	if !(normal.Len() > 0) {
+		normal = winding
+	} else if winding.Dot(normal) < 0 {
+		corners[1], corners[2] = corners[2], corners[1]
+	}
+	normal = normal.Normalize()
+
+	face := Face{UVs: [3]int{-1, -1, -1}, Group: b.group, Material: -1}
+	normalIndex := b.index(b.normals, &b.mesh.Normals, normal)
+	for c, corner := range corners {
+		face.Positions[c] = b.index(b.positions, &b.mesh.Positions, corner)
+		face.Normals[c] = normalIndex
+	}
+	b.mesh.Faces = append(b.mesh.Faces, face)
+}
+
+// index returns the index of v in values, appending it when it is new.
+func (b *stlBuilder) index(seen map[linalg.Vec3]int, values *[]linalg.Vec3, v linalg.Vec3) int {
+	if i, exists := seen[v]; exists {
+		return i
+	}
+	*values = append(*values, v)
+	seen[v] = len(*values) - 1
+	return seen[v]
+}
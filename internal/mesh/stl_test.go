@@ -0,0 +1,173 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// binarySTL encodes facets, each a normal followed by three corners, as a
+// binary STL with the given header and padding bytes appended.
+func binarySTL(header string, facets [][4][3]float32, padding int) []byte {
+	var buffer bytes.Buffer
+	head := make([]byte, stlHeaderSize)
+	copy(head, header)
+	buffer.Write(head)
+	binary.Write(&buffer, binary.LittleEndian, uint32(len(facets)))
+	for _, facet := range facets {
+		for _, v := range facet {
+			for _, component := range v {
+				binary.Write(&buffer, binary.LittleEndian, math.Float32bits(component))
+			}
+		}
+		buffer.Write([]byte{0, 0})
+	}
+	buffer.Write(make([]byte, padding))
+	return buffer.Bytes()
+}
+
+var stlSquare = [][4][3]float32{
+	{{0, 0, 1}, {0, 0, 0}, {1, 0, 0}, {1, 1, 0}},
+	{{0, 0, 1}, {0, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+}
+
+const asciiSTLSolids = `solid first
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 1 1 0
+    endloop
+  endfacet
+endsolid first
+solid second
+  facet normal 0 0 0
+    outer loop
+      vertex 0 0 1
+      vertex 1 0 1
+      vertex 1 1 1
+    endloop
+  endfacet
+  facet normal 0 0 -1
+    outer loop
+      vertex 0 0 1
+      vertex 1 1 1
+      vertex 0 1 1
+    endloop
+  endfacet
+endsolid second
+`
+
+func TestParseSTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		triangles int
+		positions int
+		groups    []string
+	}{
+		{
+			name:      "binary",
+			data:      binarySTL("exported by a tool", stlSquare, 0),
+			triangles: 2, positions: 4,
+			groups: []string{"exported by a tool"},
+		},
+		{
+			name:      "binary with a solid header",
+			data:      binarySTL("solid square", stlSquare, 0),
+			triangles: 2, positions: 4,
+			groups: []string{"square"},
+		},
+		{
+			name:      "padded binary with a solid header",
+			data:      binarySTL("solid square", stlSquare, 16),
+			triangles: 2, positions: 4,
+			groups: []string{"square"},
+		},
+		{
+			name:      "ascii with several solids",
+			data:      []byte(asciiSTLSolids),
+			triangles: 3, positions: 7,
+			groups: []string{"first", "second"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseSTL(bytes.NewReader(tt.data), "test.stl")
+			if err != nil {
+				t.Fatalf("ParseSTL: %v", err)
+			}
+			if len(m.Faces) != tt.triangles || len(m.Positions) != tt.positions {
+				t.Errorf("got %d triangles over %d positions, want %d over %d",
+					len(m.Faces), len(m.Positions), tt.triangles, tt.positions)
+			}
+			if len(m.Groups) != len(tt.groups) {
+				t.Fatalf("got %d groups, want %d", len(m.Groups), len(tt.groups))
+			}
+			for i, group := range m.Groups {
+				if group.Object != tt.groups[i] {
+					t.Errorf("group %d is %q, want %q", i, group.Object, tt.groups[i])
+				}
+			}
+
+			// Facets face along their normal, whichever way the file
+			// ordered their vertices.
+			for i, face := range m.Faces {
+				a, b, c := m.Positions[face.Positions[0]], m.Positions[face.Positions[1]], m.Positions[face.Positions[2]]
+				if b.Sub(a).Cross(c.Sub(a)).Dot(m.Normals[face.Normals[0]]) <= 0 {
+					t.Errorf("triangle %d is turned away from its normal", i)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSTLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"no facets", "solid empty\nendsolid empty\n", "test.stl: no facets found"},
+		{"bad vertex", "solid a\nfacet normal 0 0 1\nouter loop\nvertex 0 0\n", "test.stl:4: vertex"},
+		{"two vertices", "solid a\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nendloop\nendfacet\n", "test.stl:7: facet needs 3 vertices"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSTL(strings.NewReader(tt.data), "test.stl")
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Fatalf("ParseSTL = %v, want an error starting with %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Format
+	}{
+		{"binary STL", binarySTL("solid square", stlSquare, 0), FormatSTL},
+		{"padded binary STL", binarySTL("", stlSquare, 100), FormatSTL},
+		{"ascii STL", []byte(asciiSTLSolids), FormatSTL},
+		{"PLY", []byte("ply\nformat ascii 1.0\n"), FormatPLY},
+		{"OBJ", []byte("# cube\nv 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"), FormatOBJ},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head := tt.data
+			if len(head) > stlHeaderSize+4 {
+				head = head[:stlHeaderSize+4]
+			}
+			if got := sniffFormat(head, int64(len(tt.data))); got != tt.want {
+				t.Errorf("sniffFormat = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package mesh
+
+/**
+ * Splits planar polygons into triangles by ear clipping.
+ *
+ * The polygon is projected onto the plane of its Newell normal and ears are
+ * cut off one at a time, which handles concave outlines that a simple fan
+ * would get wrong. Degenerate input falls back to a fan.
+ */
+
+import "zontengine/internal/linalg"
+
+// Triangulate returns index triples into corners (0..len(corners)-1)
+// covering the polygon, preserving its winding.
+func Triangulate(corners []linalg.Vec3) [][3]int {
+	n := len(corners)
+	if n < 3 {
+		return nil
+	}
+	if n == 3 {
+		return [][3]int{{0, 1, 2}}
+	}
+
+	normal := newellNormal(corners)
+	if normal.Len() == 0 {
+		return fan(n)
+	}
+
+	// Drop the dominant axis of the normal to get a 2D outline.
+	points := make([]linalg.Vec2, n)
+	for i, c := range corners {
+		points[i] = project2D(c, normal)
+	}
+
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+	sign := 1.0
+	if signedArea(points) < 0 {
+		sign = -1
+	}
+
+	var triangles [][3]int
+	for guard := 0; len(remaining) > 3 && guard < n*n; guard++ {
+		clipped := false
+		for i := range remaining {
+			prev := remaining[(i+len(remaining)-1)%len(remaining)]
+			curr := remaining[i]
+			next := remaining[(i+1)%len(remaining)]
+
+			if !isEar(points, remaining, prev, curr, next, sign) {
+				continue
+			}
+			triangles = append(triangles, [3]int{prev, curr, next})
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			clipped = true
+			break
+		}
+		if !clipped {
+			return fan(n)
+		}
+	}
+	return append(triangles, [3]int{remaining[0], remaining[1], remaining[2]})
+}
+
+func fan(n int) [][3]int {
+	triangles := make([][3]int, 0, n-2)
+	for i := 1; i+1 < n; i++ {
+		triangles = append(triangles, [3]int{0, i, i + 1})
+	}
+	return triangles
+}
+
+func newellNormal(corners []linalg.Vec3) linalg.Vec3 {
+	var normal linalg.Vec3
+	for i, a := range corners {
+		b := corners[(i+1)%len(corners)]
+		normal.X += (a.Y - b.Y) * (a.Z + b.Z)
+		normal.Y += (a.Z - b.Z) * (a.X + b.X)
+		normal.Z += (a.X - b.X) * (a.Y + b.Y)
+	}
+	return normal
+}
+
+func project2D(p, normal linalg.Vec3) linalg.Vec2 {
+	ax, ay, az := abs(normal.X), abs(normal.Y), abs(normal.Z)
+	switch {
+	case ax >= ay && ax >= az:
+		return linalg.Vec2{X: p.Y, Y: p.Z}
+	case ay >= az:
+		return linalg.Vec2{X: p.Z, Y: p.X}
+	default:
+		return linalg.Vec2{X: p.X, Y: p.Y}
+	}
+}
+
+func signedArea(points []linalg.Vec2) float64 {
+	area := 0.0
+	for i, a := range points {
+		b := points[(i+1)%len(points)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area / 2
+}
+
+func cross2D(a, b, c linalg.Vec2) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+func isEar(points []linalg.Vec2, remaining []int, prev, curr, next int, sign float64) bool {
+	a, b, c := points[prev], points[curr], points[next]
+	if cross2D(a, b, c)*sign <= 0 {
+		return false
+	}
+	for _, i := range remaining {
+		if i == prev || i == curr || i == next {
+			continue
+		}
+		p := points[i]
+		if cross2D(a, b, p)*sign >= 0 && cross2D(b, c, p)*sign >= 0 && cross2D(c, a, p)*sign >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,48 @@
+package raster
+
+import "math"
+
+// Line calls plot for every cell of a cols x rows grid on the segment from
+// v0 to v1, stepping one cell at a time along the major axis (a DDA over
+// cell centres). Weights holds the share of v0 and v1 in the first two
+// entries. Cells outside the grid are skipped.
+func Line(cols, rows int, v0, v1 Vertex, plot func(Fragment)) {
+	x0, y0 := v0.X-0.5, v0.Y-0.5
+	dx, dy := v1.X-v0.X, v1.Y-v0.Y
+
+	steps := int(math.Ceil(math.Max(math.Abs(dx), math.Abs(dy))))
+	if steps == 0 {
+		steps = 1
+	}
+	lastX, lastY := math.MinInt, math.MinInt
+
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := int(math.Round(x0 + dx*t))
+		y := int(math.Round(y0 + dy*t))
+
+		if x == lastX && y == lastY {
+			continue
+		}
+		lastX, lastY = x, y
+		if x < 0 || x >= cols || y < 0 || y >= rows {
+			continue
+		}
+
+		plot(Fragment{
+			X:       x,
+			Y:       y,
+			Depth:   v0.Z + (v1.Z-v0.Z)*t,
+			Weights: [3]float64{1 - t, t, 0},
+		})
+	}
+}
+
+// Point calls plot for the cell containing v, if it lies on the grid.
+func Point(cols, rows int, v Vertex, plot func(Fragment)) {
+	x, y := int(math.Floor(v.X)), int(math.Floor(v.Y))
+	if x < 0 || x >= cols || y < 0 || y >= rows {
+		return
+	}
+	plot(Fragment{X: x, Y: y, Depth: v.Z, Weights: [3]float64{1, 0, 0}})
+}
@@ -0,0 +1,103 @@
+package raster
+
+/**
+ * Scan-converts screen-space triangles, lines and points into cell fragments.
+ *
+ * Vertices are given in fractional cell coordinates with (0, 0) at the
+ * top-left corner of the grid, so a vertex may sit anywhere inside a cell.
+ * A cell is covered when its centre lies inside the triangle; centres that
+ * fall exactly on an edge follow the top-left fill rule, so two triangles
+ * sharing an edge never both claim (or both miss) the same cell.
+ *
+ * Only the triangle's bounding box, clipped to the grid, is visited, which
+ * keeps the cost proportional to the triangle rather than to the screen.
+ */
+
+import "math"
+
+// Vertex is a screen-space point: X/Y in cells and Z the depth to interpolate.
+type Vertex struct {
+	X, Y, Z float64
+}
+
+// Fragment is a covered cell together with its interpolated depth and the
+// barycentric weights of the three triangle vertices at the cell centre.
+type Fragment struct {
+	X, Y    int
+	Depth   float64
+	Weights [3]float64
+}
+
+// Triangle calls plot once for every cell of a cols x rows grid covered by
+// the triangle v0, v1, v2. Winding does not matter.
+func Triangle(cols, rows int, v0, v1, v2 Vertex, plot func(Fragment)) {
+	area := edge(v0, v1, v2.X, v2.Y)
+	if area == 0 {
+		return
+	}
+
+	// Work with a single orientation so that the top-left test below holds.
+	swapped := area < 0
+	if swapped {
+		v1, v2 = v2, v1
+		area = -area
+	}
+
+	minX := math.Max(0, math.Ceil(math.Min(v0.X, math.Min(v1.X, v2.X))-0.5))
+	maxX := math.Min(float64(cols-1), math.Floor(math.Max(v0.X, math.Max(v1.X, v2.X))-0.5))
+	minY := math.Max(0, math.Ceil(math.Min(v0.Y, math.Min(v1.Y, v2.Y))-0.5))
+	maxY := math.Min(float64(rows-1), math.Floor(math.Max(v0.Y, math.Max(v1.Y, v2.Y))-0.5))
+
+	topLeft0 := isTopLeft(v1, v2)
+	topLeft1 := isTopLeft(v2, v0)
+	topLeft2 := isTopLeft(v0, v1)
+
+	for y := int(minY); y <= int(maxY); y++ {
+		py := float64(y) + 0.5
+		for x := int(minX); x <= int(maxX); x++ {
+			px := float64(x) + 0.5
+
+			w0 := edge(v1, v2, px, py)
+			w1 := edge(v2, v0, px, py)
+			w2 := edge(v0, v1, px, py)
+
+			if !covers(w0, topLeft0) || !covers(w1, topLeft1) || !covers(w2, topLeft2) {
+				continue
+			}
+
+			w0 /= area
+			w1 /= area
+			w2 /= area
+
+			weights := [3]float64{w0, w1, w2}
+			if swapped {
+				weights[1], weights[2] = w2, w1
+			}
+
+			plot(Fragment{
+				X:       x,
+				Y:       y,
+				Depth:   w0*v0.Z + w1*v1.Z + w2*v2.Z,
+				Weights: weights,
+			})
+		}
+	}
+}
+
+// edge is the signed doubled area of (a, b, p); positive when p lies to the
+// right of a->b in y-down screen space.
+func edge(a, b Vertex, px, py float64) float64 {
+	return (b.X-a.X)*(py-a.Y) - (b.Y-a.Y)*(px-a.X)
+}
+
+// isTopLeft reports whether a->b is a top edge (horizontal, interior below)
+// or a left edge for triangles with positive edge() area.
+func isTopLeft(a, b Vertex) bool {
+	dx := b.X - a.X
+	dy := b.Y - a.Y
+	return (dy == 0 && dx > 0) || dy < 0
+}
+
+func covers(w float64, topLeft bool) bool {
+	return w > 0 || (w == 0 && topLeft)
+}
@@ -0,0 +1,144 @@
+package raster
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTriangleSharedEdges(t *testing.T) {
+	// Every case tiles the rectangle (0, 0)-(w, h) of its grid with
+	// triangles; several put edges and vertices exactly on cell centres.
+	tests := []struct {
+		name      string
+		cols      int
+		rows      int
+		w, h      float64
+		triangles [][3]Vertex
+	}{
+		{
+			name: "square split on the diagonal",
+			cols: 8, rows: 8, w: 8, h: 8,
+			triangles: [][3]Vertex{
+				{{X: 0, Y: 0}, {X: 8, Y: 0}, {X: 8, Y: 8}},
+				{{X: 0, Y: 0}, {X: 8, Y: 8}, {X: 0, Y: 8}},
+			},
+		},
+		{
+			name: "opposite windings",
+			cols: 8, rows: 8, w: 8, h: 8,
+			triangles: [][3]Vertex{
+				{{X: 0, Y: 0}, {X: 8, Y: 8}, {X: 8, Y: 0}},
+				{{X: 0, Y: 0}, {X: 8, Y: 8}, {X: 0, Y: 8}},
+			},
+		},
+		{
+			name: "vertical edge through centres",
+			cols: 6, rows: 4, w: 6, h: 4,
+			triangles: [][3]Vertex{
+				{{X: 0, Y: 0}, {X: 2.5, Y: 0}, {X: 2.5, Y: 4}},
+				{{X: 0, Y: 0}, {X: 2.5, Y: 4}, {X: 0, Y: 4}},
+				{{X: 2.5, Y: 0}, {X: 6, Y: 0}, {X: 6, Y: 4}},
+				{{X: 2.5, Y: 0}, {X: 6, Y: 4}, {X: 2.5, Y: 4}},
+			},
+		},
+		{
+			name: "horizontal edge through centres",
+			cols: 4, rows: 6, w: 4, h: 6,
+			triangles: [][3]Vertex{
+				{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 3.5}},
+				{{X: 0, Y: 0}, {X: 4, Y: 3.5}, {X: 0, Y: 3.5}},
+				{{X: 0, Y: 3.5}, {X: 4, Y: 3.5}, {X: 4, Y: 6}},
+				{{X: 0, Y: 3.5}, {X: 4, Y: 6}, {X: 0, Y: 6}},
+			},
+		},
+		{
+			name: "fan around a cell centre",
+			cols: 7, rows: 7, w: 7, h: 7,
+			triangles: [][3]Vertex{
+				{{X: 3.5, Y: 3.5}, {X: 0, Y: 0}, {X: 7, Y: 0}},
+				{{X: 3.5, Y: 3.5}, {X: 7, Y: 0}, {X: 7, Y: 7}},
+				{{X: 3.5, Y: 3.5}, {X: 7, Y: 7}, {X: 0, Y: 7}},
+				{{X: 3.5, Y: 3.5}, {X: 0, Y: 7}, {X: 0, Y: 0}},
+			},
+		},
+		{
+			name: "fractional fan",
+			cols: 10, rows: 10, w: 10, h: 10,
+			triangles: [][3]Vertex{
+				{{X: 4.3, Y: 6.1}, {X: 0, Y: 0}, {X: 10, Y: 0}},
+				{{X: 4.3, Y: 6.1}, {X: 10, Y: 0}, {X: 10, Y: 10}},
+				{{X: 4.3, Y: 6.1}, {X: 10, Y: 10}, {X: 0, Y: 10}},
+				{{X: 4.3, Y: 6.1}, {X: 0, Y: 10}, {X: 0, Y: 0}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			covered := make([][]int, tt.rows)
+			for y := range covered {
+				covered[y] = make([]int, tt.cols)
+			}
+			for _, triangle := range tt.triangles {
+				Triangle(tt.cols, tt.rows, triangle[0], triangle[1], triangle[2], func(f Fragment) {
+					covered[f.Y][f.X]++
+				})
+			}
+
+			for y, row := range covered {
+				for x, count := range row {
+					cx, cy := float64(x)+0.5, float64(y)+0.5
+					inside := cx < tt.w && cy < tt.h
+					if inside && count != 1 || !inside && count != 0 {
+						t.Errorf("cell (%d, %d) covered %d times", x, y, count)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTriangleWeights(t *testing.T) {
+	tests := []struct {
+		name       string
+		v0, v1, v2 Vertex
+	}{
+		{"clockwise", Vertex{X: 1, Y: 1, Z: 0}, Vertex{X: 9, Y: 2, Z: 1}, Vertex{X: 4, Y: 8, Z: 2}},
+		{"counter-clockwise", Vertex{X: 1, Y: 1, Z: 0}, Vertex{X: 4, Y: 8, Z: 2}, Vertex{X: 9, Y: 2, Z: 1}},
+		{"on centres", Vertex{X: 0.5, Y: 0.5, Z: 1}, Vertex{X: 7.5, Y: 0.5, Z: 1}, Vertex{X: 0.5, Y: 7.5, Z: 1}},
+		{"clipped by the grid", Vertex{X: -5, Y: -3, Z: 0.5}, Vertex{X: 15, Y: 1, Z: -0.5}, Vertex{X: 2, Y: 14, Z: 0}},
+	}
+
+	const epsilon = 1e-9
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fragments := 0
+			Triangle(10, 10, tt.v0, tt.v1, tt.v2, func(f Fragment) {
+				fragments++
+				w := f.Weights
+				if sum := w[0] + w[1] + w[2]; math.Abs(sum-1) > epsilon {
+					t.Errorf("cell (%d, %d): weights %v sum to %v", f.X, f.Y, w, sum)
+				}
+				for _, weight := range w {
+					if weight < -epsilon || weight > 1+epsilon {
+						t.Errorf("cell (%d, %d): weight %v outside the triangle", f.X, f.Y, weight)
+					}
+				}
+
+				// The weights belong to the vertices in the order given.
+				x := w[0]*tt.v0.X + w[1]*tt.v1.X + w[2]*tt.v2.X
+				y := w[0]*tt.v0.Y + w[1]*tt.v1.Y + w[2]*tt.v2.Y
+				if math.Abs(x-float64(f.X)-0.5) > epsilon || math.Abs(y-float64(f.Y)-0.5) > epsilon {
+					t.Errorf("cell (%d, %d): weights %v point at (%v, %v)", f.X, f.Y, w, x, y)
+				}
+				depth := w[0]*tt.v0.Z + w[1]*tt.v1.Z + w[2]*tt.v2.Z
+				if math.Abs(depth-f.Depth) > epsilon {
+					t.Errorf("cell (%d, %d): depth %v, weights give %v", f.X, f.Y, f.Depth, depth)
+				}
+			})
+			if fragments == 0 {
+				t.Error("no cell covered")
+			}
+		})
+	}
+}
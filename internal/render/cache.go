@@ -0,0 +1,115 @@
+package render
+
+/**
+ * Bounded caches used by the renderer between frames.
+ *
+ * Every cache is an LRU with a fixed capacity, so a long-running animation
+ * uses a constant amount of memory. Angles are reduced modulo a full turn
+ * and quantized to AngleStep before being used as keys, which lets a
+ * turntable hit the same entries on every revolution.
+ */
+
+import (
+	"math"
+	"zontengine/internal/cache"
+	"zontengine/internal/linalg"
+	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+	"zontengine/internal/rotate"
+)
+
+// AngleStep is the resolution, in radians, that animation angles are
+// snapped to before rendering (a tenth of a degree).
+const AngleStep = math.Pi / 1800
+
+// CacheOptions sets the capacity of each cache; zero disables that cache.
+type CacheOptions struct {
+	// Rotations holds model orientations keyed by quantized angle.
+	Rotations int
+	// Frames holds the visible, projected triangles of one model keyed by
+	// quantized angle. It only pays off when the same angles come back
+	// exactly, as when a turntable is rendered more than once; the angles
+	// of Render follow the clock and hardly ever repeat.
+	Frames int
+	// Projections holds projected vertices keyed by world position.
+	Projections int
+	// Normals holds face normals keyed by the face's vertices in order.
+	Normals int
+}
+
+// DefaultCacheOptions enables only the rotation cache, whose entries are
+// small enough to keep one per quantized angle of a turn. The frame cache is
+// opt-in (see CacheOptions.Frames), and the projection and normal caches stay
+// off: they are keyed by rotated coordinates, which almost never repeat, so
+// they would only add work and allocations.
+func DefaultCacheOptions() CacheOptions {
+	return CacheOptions{
+		Rotations: 3600,
+	}
+}
+
+type CacheStats struct {
+	Rotations   cache.Stats
+	Frames      cache.Stats
+	Projections cache.Stats
+	Normals     cache.Stats
+}
+
+type renderCache struct {
+	rotations   *cache.LRU[int64, rotate.Quaternion]
+	frames      *cache.LRU[int64, []matrix.Triangle]
+	projections *cache.LRU[linalg.Vec3, linalg.Vec3]
+	normals     *cache.LRU[[3]linalg.Vec3, linalg.Vec3]
+
+	// model is the mesh the entries of frames belong to.
+	model *mesh.Mesh
+}
+
+func newRenderCache(opts CacheOptions) renderCache {
+	return renderCache{
+		rotations:   cache.NewLRU[int64, rotate.Quaternion](opts.Rotations),
+		frames:      cache.NewLRU[int64, []matrix.Triangle](opts.Frames),
+		projections: cache.NewLRU[linalg.Vec3, linalg.Vec3](opts.Projections),
+		normals:     cache.NewLRU[[3]linalg.Vec3, linalg.Vec3](opts.Normals),
+	}
+}
+
+// SetCacheOptions resizes the caches, evicting entries that no longer fit.
+func (r *Render) SetCacheOptions(opts CacheOptions) {
+	r.cache.rotations.Resize(opts.Rotations)
+	r.cache.frames.Resize(opts.Frames)
+	r.cache.projections.Resize(opts.Projections)
+	r.cache.normals.Resize(opts.Normals)
+}
+
+// DisableCache turns every cache off; each frame is computed from scratch.
+func (r *Render) DisableCache() {
+	r.SetCacheOptions(CacheOptions{})
+}
+
+func (r *Render) CacheStats() CacheStats {
+	return CacheStats{
+		Rotations:   r.cache.rotations.Stats(),
+		Frames:      r.cache.frames.Stats(),
+		Projections: r.cache.projections.Stats(),
+		Normals:     r.cache.normals.Stats(),
+	}
+}
+
+func (r *Render) ClearCache() {
+	r.cache.rotations.Clear()
+	r.cache.frames.Clear()
+	r.cache.projections.Clear()
+	r.cache.normals.Clear()
+	r.cache.model = nil
+}
+
+// angleKey reduces angle to [0, 2π) and quantizes it to AngleStep.
+func angleKey(angle float64) int64 {
+	turns := int64(math.Round(2 * math.Pi / AngleStep))
+	key := int64(math.Round(angle/AngleStep)) % turns
+	if key < 0 {
+		key += turns
+	}
+	return key
+}
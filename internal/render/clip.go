@@ -0,0 +1,98 @@
+package render
+
+/**
+ * Clipping of triangles against the near and far planes.
+ *
+ * A triangle reaching past either plane is cut along it in world space
+ * (Sutherland-Hodgman, one plane at a time) and the remaining polygon, of
+ * up to five corners, is fanned back into triangles. Normals, texture
+ * coordinates and vertex colors are interpolated along the cut edges, so
+ * a model seen from up close loses only what lies in front of the near
+ * plane instead of whole triangles.
+ */
+
+import (
+	"zontengine/internal/linalg"
+	"zontengine/internal/matrix"
+)
+
+// clipCorner is a triangle corner with the attributes carried through
+// clipping.
+type clipCorner struct {
+	position linalg.Vec3
+	normal   linalg.Vec3
+	uv       linalg.Vec2
+	color    linalg.Vec3
+}
+
+func (a clipCorner) lerp(b clipCorner, t float64) clipCorner {
+	return clipCorner{
+		position: a.position.Lerp(b.position, t),
+		normal:   a.normal.Lerp(b.normal, t),
+		uv:       a.uv.Scale(1 - t).Add(b.uv.Scale(t)),
+		color:    a.color.Lerp(b.color, t),
+	}
+}
+
+// inClipRange reports whether every corner of the triangle lies between
+// the near and far planes.
+func (r *Render) inClipRange(triangle *matrix.Triangle) bool {
+	for _, vertex := range triangle.Verts {
+		if !r.camera.InClipRange(vertex) {
+			return false
+		}
+	}
+	return true
+}
+
+// clipTriangle appends to visible the parts of triangle that lie between
+// the near and far planes, projected. triangle has its world-space corners
+// and attributes set; its Projected field is ignored.
+func (r *Render) clipTriangle(visible []matrix.Triangle, triangle matrix.Triangle) []matrix.Triangle {
+	polygon := make([]clipCorner, 3, 5)
+	for c := range polygon {
+		polygon[c] = clipCorner{
+			position: triangle.Verts[c],
+			normal:   triangle.Normals[c],
+			uv:       triangle.UVs[c],
+			color:    triangle.Colors[c],
+		}
+	}
+
+	near, far := r.camera.Near, r.camera.Far
+	polygon = clipPolygon(polygon, func(v linalg.Vec3) float64 { return r.camera.Depth(v) - near })
+	polygon = clipPolygon(polygon, func(v linalg.Vec3) float64 { return far - r.camera.Depth(v) })
+
+	for i := 1; i+1 < len(polygon); i++ {
+		part := triangle
+		for c, corner := range [3]clipCorner{polygon[0], polygon[i], polygon[i+1]} {
+			part.Verts[c] = corner.position
+			part.Normals[c] = corner.normal
+			part.UVs[c] = corner.uv
+			part.Colors[c] = corner.color
+			part.Projected[c] = r.getProjectedVertex(corner.position)
+		}
+		visible = append(visible, part)
+	}
+	return visible
+}
+
+// clipPolygon returns the part of the convex polygon where distance is not
+// negative.
+func clipPolygon(polygon []clipCorner, distance func(linalg.Vec3) float64) []clipCorner {
+	if len(polygon) == 0 {
+		return polygon
+	}
+	clipped := make([]clipCorner, 0, len(polygon)+1)
+	for i, current := range polygon {
+		next := polygon[(i+1)%len(polygon)]
+		dc, dn := distance(current.position), distance(next.position)
+		if dc >= 0 {
+			clipped = append(clipped, current)
+		}
+		if (dc >= 0) != (dn >= 0) {
+			clipped = append(clipped, current.lerp(next, dc/(dc-dn)))
+		}
+	}
+	return clipped
+}
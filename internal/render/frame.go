@@ -0,0 +1,131 @@
+package render
+
+/**
+ * Headless rendering into a Frame value.
+ *
+ * RenderFrame runs the same pipeline as Render for a single pose and
+ * camera but keeps the result instead of drawing it, so it can be used
+ * from tests, servers and exporters without a terminal. It does not touch
+ * the output or start goroutines. RenderTurntable does the same for a whole
+ * revolution, stepping the matrix angle like the render loop.
+ */
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"zontengine/internal/camera"
+	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+	"zontengine/internal/rotate"
+)
+
+// Frame is one rendered image.
+type Frame struct {
+	Cols int
+	Rows int
+	// Cells holds Rows rows of Cols cells: characters and their colors.
+	Cells [][]matrix.Cell
+	// Depth is the normalized device depth (-1 near to 1 far) of the surface
+	// drawn in each cell, +Inf where nothing was drawn.
+	Depth [][]float64
+}
+
+// NewFrame returns a blank cols x rows frame.
+func NewFrame(cols, rows int) *Frame {
+	f := &Frame{
+		Cols:  cols,
+		Rows:  rows,
+		Cells: make([][]matrix.Cell, rows),
+		Depth: matrix.NewDepthBuffer(cols, rows),
+	}
+	for row := range f.Cells {
+		f.Cells[row] = make([]matrix.Cell, cols)
+		for col := range f.Cells[row] {
+			f.Cells[row][col] = matrix.BlankCell
+		}
+	}
+	return f
+}
+
+// Covered reports whether anything was drawn in the cell.
+func (f *Frame) Covered(col, row int) bool {
+	return !math.IsInf(f.Depth[row][col], 1)
+}
+
+// String returns the characters of the frame, rows separated by newlines.
+func (f *Frame) String() string {
+	var result strings.Builder
+	for row, cells := range f.Cells {
+		if row > 0 {
+			result.WriteByte('\n')
+		}
+		for _, cell := range cells {
+			result.WriteRune(cell.Ch)
+		}
+	}
+	return result.String()
+}
+
+// RenderFrame renders the model in the given orientation, seen through cam
+// (nil for the renderer's camera), at the size of the matrix and with the
+// renderer's draw settings. It must not be called while Render is running
+// on the same renderer.
+func (r *Render) RenderFrame(model *mesh.Mesh, orientation rotate.Quaternion, cam *camera.Camera) (*Frame, error) {
+	if err := checkModel(model); err != nil {
+		return nil, err
+	}
+
+	defer r.pose(orientation, cam)()
+
+	return r.drawFrame(model, r.transformTriangles(model, r.rotate.Matrix())), nil
+}
+
+// RenderTurntable renders count frames of one full turn around the spin
+// axis, starting from the current matrix angle, which is restored before
+// returning. Frames share the angle caches with Render. Like RenderFrame it
+// must not run alongside Render.
+func (r *Render) RenderTurntable(model *mesh.Mesh, count int) ([]*Frame, error) {
+	if err := checkModel(model); err != nil {
+		return nil, err
+	}
+	if count < 1 {
+		return nil, errors.New("render: turntable needs at least one frame")
+	}
+
+	startAngle := r.matrix.GetAngle()
+	defer r.matrix.SetAngle(startAngle)
+
+	frames := make([]*Frame, count)
+	for i := range frames {
+		r.matrix.SetAngle(startAngle + 2*math.Pi*float64(i)/float64(count))
+		r.updateCamera()
+		r.updateRotation()
+		frames[i] = r.drawFrame(model, r.processVertices(model))
+	}
+	return frames, nil
+}
+
+// pose prepares a single render in the given orientation through cam (nil
+// for the renderer's camera) and returns the function restoring the camera
+// and the orientation in use before.
+func (r *Render) pose(orientation rotate.Quaternion, cam *camera.Camera) (restore func()) {
+	previousCamera, previousOrientation := r.camera, r.rotate.Orientation()
+	if cam != nil {
+		r.camera = cam
+	}
+	r.updateCamera()
+	r.rotate.Set(orientation.Normalize())
+	return func() {
+		r.camera = previousCamera
+		r.rotate.Set(previousOrientation)
+		r.updateCamera()
+	}
+}
+
+// drawFrame draws the visible triangles of the model into a new frame.
+func (r *Render) drawFrame(model *mesh.Mesh, visible []matrix.Triangle) *Frame {
+	frame := NewFrame(r.matrix.GetCols(), r.matrix.GetRows())
+	r.drawTriangles(frame.Cells, frame.Depth, r.orderTriangles(visible), model)
+	return frame
+}
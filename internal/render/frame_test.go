@@ -0,0 +1,172 @@
+package render
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"zontengine/internal/camera"
+	"zontengine/internal/linalg"
+	"zontengine/internal/rotate"
+)
+
+// checkFrame fails the test unless frame is cols x rows, has something
+// drawn, and every cell is either drawn (a visible character, a color and
+// a depth in the clip range) or blank with infinite depth.
+func checkFrame(t *testing.T, frame *Frame, cols, rows int) {
+	t.Helper()
+	if frame.Cols != cols || frame.Rows != rows || len(frame.Cells) != rows || len(frame.Depth) != rows {
+		t.Fatalf("frame is %dx%d with %d rows of cells and %d of depth, want %dx%d",
+			frame.Cols, frame.Rows, len(frame.Cells), len(frame.Depth), cols, rows)
+	}
+
+	covered := 0
+	for row := 0; row < rows; row++ {
+		if len(frame.Cells[row]) != cols || len(frame.Depth[row]) != cols {
+			t.Fatalf("row %d has %d cells and %d depths, want %d", row, len(frame.Cells[row]), len(frame.Depth[row]), cols)
+		}
+		for col := 0; col < cols; col++ {
+			cell, depth := frame.Cells[row][col], frame.Depth[row][col]
+			if !frame.Covered(col, row) {
+				if cell.Ch != ' ' || !cell.Fg.IsDefault() {
+					t.Errorf("uncovered cell (%d, %d) holds %q in %s", col, row, cell.Ch, cell.Fg.Hex())
+				}
+				continue
+			}
+			covered++
+			if cell.Ch == ' ' || cell.Fg.IsDefault() {
+				t.Errorf("covered cell (%d, %d) holds %q in %s", col, row, cell.Ch, cell.Fg.Hex())
+			}
+			if depth < -1 || depth > 1 {
+				t.Errorf("cell (%d, %d) has depth %v outside [-1, 1]", col, row, depth)
+			}
+		}
+	}
+	if covered == 0 {
+		t.Fatal("nothing drawn")
+	}
+	if lines := strings.Split(frame.String(), "\n"); len(lines) != rows {
+		t.Errorf("String has %d lines, want %d", len(lines), rows)
+	}
+}
+
+func TestRenderFrame(t *testing.T) {
+	tests := []struct {
+		name      string
+		depthMode DepthMode
+	}{
+		{"depth buffer", DepthBuffer},
+		{"painter", DepthPainter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestRender(40, 20)
+			r.SetDepthMode(tt.depthMode)
+			frame, err := r.RenderFrame(tetrahedron(), rotate.FromAxisAngle(1, 1, 0, 0.5), nil)
+			if err != nil {
+				t.Fatalf("RenderFrame: %v", err)
+			}
+			checkFrame(t, frame, 40, 20)
+		})
+	}
+}
+
+func TestRenderFrameRestoresPose(t *testing.T) {
+	r, _ := newTestRender(40, 20)
+	callerCamera := r.GetCamera()
+	callerOrientation := rotate.FromAxisAngle(0, 1, 0, 1)
+	r.rotate.Set(callerOrientation)
+	r.updateCamera()
+	callerViewProjection := r.viewProjection
+
+	other := camera.NewCamera()
+	other.Position = linalg.Vec3{X: 2, Y: 2, Z: -2}
+	other.Projection = camera.Orthographic
+
+	if _, err := r.RenderFrame(tetrahedron(), rotate.FromAxisAngle(1, 0, 0, 2), other); err != nil {
+		t.Fatalf("RenderFrame: %v", err)
+	}
+	if r.GetCamera() != callerCamera {
+		t.Error("RenderFrame left its camera in place")
+	}
+	if r.rotate.Orientation() != callerOrientation {
+		t.Errorf("orientation is %v after RenderFrame, want %v", r.rotate.Orientation(), callerOrientation)
+	}
+	if r.viewProjection != callerViewProjection {
+		t.Error("RenderFrame left the projection of its camera in place")
+	}
+}
+
+func TestRenderTurntable(t *testing.T) {
+	r, _ := newTestRender(40, 20)
+	r.matrix.SetAngle(0.25)
+
+	frames, err := r.RenderTurntable(tetrahedron(), 4)
+	if err != nil {
+		t.Fatalf("RenderTurntable: %v", err)
+	}
+	if len(frames) != 4 {
+		t.Fatalf("got %d frames, want 4", len(frames))
+	}
+	for i, frame := range frames {
+		checkFrame(t, frame, 40, 20)
+		if i > 0 && frame.String() == frames[i-1].String() {
+			t.Errorf("frames %d and %d are the same", i-1, i)
+		}
+	}
+	if angle := r.matrix.GetAngle(); angle != 0.25 {
+		t.Errorf("angle is %v after the turntable, want 0.25", angle)
+	}
+
+	// A full turn comes back to the first frame.
+	again, err := r.RenderTurntable(tetrahedron(), 1)
+	if err != nil {
+		t.Fatalf("RenderTurntable: %v", err)
+	}
+	if again[0].String() != frames[0].String() {
+		t.Error("the turntable does not start from the current angle")
+	}
+}
+
+func TestRenderTurntableCount(t *testing.T) {
+	r, _ := newTestRender(40, 20)
+	for _, count := range []int{0, -1, math.MinInt} {
+		frames, err := r.RenderTurntable(tetrahedron(), count)
+		if err == nil || frames != nil {
+			t.Errorf("RenderTurntable(%d) = %d frames, %v; want an error", count, len(frames), err)
+		}
+	}
+}
+
+func TestTurntableFrameCache(t *testing.T) {
+	model := tetrahedron()
+
+	// The frame cache is off unless asked for.
+	r, _ := newTestRender(40, 20)
+	uncached, err := r.RenderTurntable(model, 4)
+	if err != nil {
+		t.Fatalf("RenderTurntable: %v", err)
+	}
+	if stats := r.CacheStats().Frames; stats.Capacity != 0 || stats.Size != 0 {
+		t.Fatalf("default frame cache holds %d of %d frames, want it off", stats.Size, stats.Capacity)
+	}
+
+	// Once enabled, a second turn hits every frame of the first.
+	r, _ = newTestRender(40, 20)
+	r.SetCacheOptions(CacheOptions{Rotations: 3600, Frames: 4})
+	for turn := 0; turn < 2; turn++ {
+		frames, err := r.RenderTurntable(model, 4)
+		if err != nil {
+			t.Fatalf("RenderTurntable: %v", err)
+		}
+		for i := range frames {
+			if frames[i].String() != uncached[i].String() {
+				t.Errorf("turn %d: cached frame %d differs", turn, i)
+			}
+		}
+	}
+	if stats := r.CacheStats().Frames; stats.Hits != 4 || stats.Misses != 4 {
+		t.Errorf("frame cache had %d hits and %d misses, want 4 and 4", stats.Hits, stats.Misses)
+	}
+}
@@ -0,0 +1,73 @@
+package render
+
+/**
+ * Vector output of the visible triangles.
+ *
+ * RenderPolygons runs the geometry half of the pipeline (rotation, culling,
+ * projection) and hands back the visible triangles in cell coordinates
+ * instead of rasterizing them, each with the flat color fillTriangle lights
+ * it with. Vector exporters such as SVG draw them in order, so they come
+ * sorted back to front whatever the depth mode.
+ */
+
+import (
+	"errors"
+	"zontengine/internal/camera"
+	"zontengine/internal/linalg"
+	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+	"zontengine/internal/rotate"
+)
+
+// Polygon is one visible triangle of a rendered model.
+type Polygon struct {
+	// Points are the projected corners in cells: (0, 0) is the top-left
+	// corner of the frame and (Cols, Rows) the bottom-right one.
+	Points [3]linalg.Vec2
+	// Depth is the average normalized device depth of the corners.
+	Depth float64
+	// Color is the lit color of the face, from its face normal and, when
+	// textured or colored, the texel and the vertex color at its center.
+	Color matrix.Color
+	// Opacity is the dissolve of the material, 1 being fully opaque.
+	Opacity float64
+}
+
+// RenderPolygons returns the visible triangles of the model in the given
+// orientation, seen through cam (nil for the renderer's camera), in the
+// order they have to be painted. Like RenderFrame it must not run
+// alongside Render.
+func (r *Render) RenderPolygons(model *mesh.Mesh, orientation rotate.Quaternion, cam *camera.Camera) ([]Polygon, error) {
+	if model == nil || len(model.Faces) == 0 {
+		return nil, errors.New("render: model has no triangles")
+	}
+	defer r.pose(orientation, cam)()
+
+	triangles := r.matrix.SortVerts(r.transformTriangles(model, r.rotate.Matrix()))
+	lightDirection := r.camera.Forward().Neg().Normalize()
+	cols, rows := r.matrix.GetCols(), r.matrix.GetRows()
+
+	polygons := make([]Polygon, len(triangles))
+	for i := range triangles {
+		triangle := &triangles[i]
+		material := model.MaterialAt(triangle.Material)
+
+		diffuse := material.Diffuse
+		if triangle.Textured && material.Texture != nil {
+			center := triangle.UVs[0].Add(triangle.UVs[1]).Add(triangle.UVs[2]).Scale(1.0 / 3)
+			diffuse = material.SampleDiffuse(center)
+		}
+
+		polygon := Polygon{
+			Color:   matrix.ColorFromVec3(lightColor(material, diffuse.Mul(flatTint(triangle)), triangle.Normal, lightDirection)),
+			Opacity: material.Dissolve,
+		}
+		for c, vertex := range triangle.Projected {
+			x, y := camera.Viewport(vertex.X, vertex.Y, cols, rows)
+			polygon.Points[c] = linalg.Vec2{X: x, Y: y}
+			polygon.Depth += vertex.Z / 3
+		}
+		polygons[i] = polygon
+	}
+	return polygons, nil
+}
@@ -7,546 +7,595 @@ package render
  * @param matrix  the matrix instance used for screen buffering and mathematical operations
  * @param screen  the screen instance responsible for terminal display
  * @param rotate  the rotation manager for 3D transformations
+ * @param camera  the viewer driving projection, culling and screen mapping
  *
  * The renderer supports:
- * - Loading OBJ files and extracting vertices/faces
- * - Real-time rotation animation with FPS control
+ * - Rendering indexed meshes loaded by package mesh from OBJ, STL or PLY
+ *   files (see LoadModel), and faceless meshes as point clouds
+ * - Posing the model with any orientation and spinning it around an axis
+ * - Real-time rotation animation with FPS control, cancellable through a
+ *   context and optionally limited to a number of frames or a duration
+ * - Following the terminal size while running (see Options.Resize)
+ * - Incremental terminal output that only rewrites changed cells, with
+ *   per-frame byte counts (see SetFullRedraw and DrawStats)
+ * - Drawing on the alternate screen with the cursor hidden, restoring the
+ *   terminal however Render ends (see Options.FullScreen)
+ * - Frames handed to the drawing goroutine through the matrix triple buffer,
+ *   with the animation angle driven by a single clock in the render loop
  * - Backface culling using surface normals
- * - Projection from 3D to 2D coordinates
- * - Triangle rasterization with line drawing and area filling
- * - Lighting simulation using ASCII character shading
- * - Comprehensive caching system for performance optimization
+ * - Perspective or orthographic projection through a configurable camera,
+ *   corrected for the width-to-height ratio of terminal cells
+ * - Triangle rasterization with a top-left fill rule (see package raster)
+ * - Hidden surface removal with a per-cell depth buffer or painter's sort
+ * - Lighting simulation using ASCII character shading, flat per face or
+ *   interpolated from vertex normals when the model provides them, with
+ *   per-material colors, textures and ramps (see shading.go)
+ * - Lit colors written to every cell and shown in 16, 256 or 24-bit color
+ *   depending on the terminal (see SetColorProfile), tinted by the vertex
+ *   colors of models that have them
+ * - Half-block, quadrant and braille pixel modes with 2, 4 or 8 pixels per
+ *   cell (see SetPixelMode)
+ * - Solid, wireframe and point cloud draw styles (see style.go)
+ * - Bounded caching system for performance optimization (see cache.go)
  */
 
 import (
-	"bufio"
-	"math"
-	"os"
-	"strconv"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"strings"
-	"sync"
 	"time"
-	"zontengine/internal/convert"
+	"zontengine/internal/camera"
+	"zontengine/internal/canvas"
+	"zontengine/internal/linalg"
 	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+	"zontengine/internal/raster"
 	"zontengine/internal/rotate"
 	"zontengine/internal/screen"
-)
+	"zontengine/internal/terminal"
 
-type Render struct {
-	matrix *matrix.Matrix
-	screen *screen.Screen
-	rotate *rotate.Rotate
+	"github.com/muesli/termenv"
+)
 
-	// Кэширование
-	cacheMutex       sync.RWMutex
-	rotationCache    map[float64][][][]float64
-	transformedVerts map[float64][][]float64
-	projectionCache  map[[3]float64][]float64
-	normalCache      map[[3]float64][]float64
-}
+// DepthMode selects how overlapping triangles are resolved.
+type DepthMode int
 
-func NewRender(matrix *matrix.Matrix) *Render {
-	return &Render{
-		matrix: matrix,
-		screen: screen.NewScreen(matrix),
-		rotate: rotate.NewRotate(),
+const (
+	// DepthBuffer keeps the closest fragment per cell using Matrix.DepthBuffer.
+	DepthBuffer DepthMode = iota
+	// DepthPainter draws triangles back to front as sorted by Matrix.SortVerts.
+	DepthPainter
+)
 
-		rotationCache:    make(map[float64][][][]float64),
-		transformedVerts: make(map[float64][][]float64),
-		projectionCache:  make(map[[3]float64][]float64),
-		normalCache:      make(map[[3]float64][]float64),
+func (m DepthMode) String() string {
+	if m == DepthPainter {
+		return "painter"
 	}
+	return "buffer"
 }
 
-func (r *Render) Render(verts [][]float64) {
-	go r.renderThread()
-
-	for {
-		r.updateRotation()
-		visibleVerts := r.processVertices(verts)
-		vertsToRender := r.matrix.SortVerts(visibleVerts)
-
-		r.screen.InitScreen(r.matrix.ScreenBuffer[0])
-
-		for i := 0; i < len(vertsToRender); i += 4 {
-			if i+3 < len(vertsToRender) {
-				r.fillTriangle(vertsToRender[i], vertsToRender[i+1], vertsToRender[i+2], vertsToRender[i+3])
-			}
-		}
-
-		for i := 0; i < len(r.matrix.ScreenBuffer[0]); i++ {
-			copy(r.matrix.ScreenBuffer[1][i], r.matrix.ScreenBuffer[0][i])
+// ParseDepthMode converts the name of a depth mode as returned by String.
+func ParseDepthMode(name string) (DepthMode, error) {
+	for _, mode := range []DepthMode{DepthBuffer, DepthPainter} {
+		if strings.EqualFold(name, mode.String()) {
+			return mode, nil
 		}
 	}
+	return DepthBuffer, fmt.Errorf("unknown depth mode %q (want buffer or painter)", name)
 }
 
-func (r *Render) RenderFrontFace(verts [][]float64) string {
-	tempBuffer := make([][]rune, len(r.matrix.ScreenBuffer[0]))
-	for i := range tempBuffer {
-		tempBuffer[i] = make([]rune, len(r.matrix.ScreenBuffer[0][0]))
-		for j := range tempBuffer[i] {
-			tempBuffer[i][j] = ' '
-		}
-	}
+// DefaultFPS is the frame rate used when Options.FPS is zero.
+const DefaultFPS = 60
+
+// DefaultCellAspect is the width of a terminal cell divided by its height
+// assumed when it is not configured or detected; most fonts are about
+// twice as tall as they are wide.
+const DefaultCellAspect = 0.5
+
+// DefaultSpeed is the spin speed in radians per second used when
+// Options.Speed is zero.
+const DefaultSpeed = 1.8
+
+// Options controls how long Render runs and how often it draws.
+type Options struct {
+	// FPS is the number of frames rendered and drawn per second.
+	FPS int
+	// Speed is how fast the model spins, in radians per second.
+	Speed float64
+	// Frames stops the loop after this many drawn frames; zero means no limit.
+	Frames int
+	// Duration stops the loop after this much time; zero means no limit.
+	Duration time.Duration
+	// Resize delivers terminal sizes to fit the image to while running,
+	// e.g. from terminal.WatchResize; nil keeps the matrix size.
+	Resize <-chan terminal.Size
+	// FullScreen draws on the terminal's alternate screen with the cursor
+	// hidden and line wrap disabled, and restores the terminal when Render
+	// returns or panics. Cancel the context on SIGINT and SIGTERM (see
+	// signal.NotifyContext) so that they restore it too.
+	FullScreen bool
+}
 
-	originalAngle := r.matrix.GetAngle()
-	r.matrix.SetAngle(0)
-	r.updateRotation()
+type Render struct {
+	matrix    *matrix.Matrix
+	screen    *screen.Screen
+	rotate    *rotate.Rotate
+	camera    *camera.Camera
+	depthMode DepthMode
 
-	visibleVerts := r.processVertices(verts)
-	vertsToRender := r.matrix.SortVerts(visibleVerts)
+	orientation rotate.Quaternion
+	spinAxis    linalg.Vec3
 
-	for i := 0; i < len(vertsToRender); i += 4 {
-		if i+3 < len(vertsToRender) {
-			r.fillTriangleToBuffer(tempBuffer, vertsToRender[i], vertsToRender[i+1], vertsToRender[i+2], vertsToRender[i+3])
-		}
-	}
+	ramp          []rune
+	materialRamps map[string][]rune
 
-	r.matrix.SetAngle(originalAngle)
+	drawStyle  DrawStyle
+	pixelMode  canvas.Mode
+	canvas     *canvas.Canvas
+	cellAspect float64
 
-	var result strings.Builder
-	for i := 0; i < len(tempBuffer); i++ {
-		for j := 0; j < len(tempBuffer[i]); j++ {
-			result.WriteRune(tempBuffer[i][j])
-		}
-		if i < len(tempBuffer)-1 {
-			result.WriteString("\n")
-		}
-	}
+	viewProjection linalg.Mat4
 
-	return result.String()
+	// Кэширование
+	cache renderCache
 }
 
-func (r *Render) fillTriangleToBuffer(buffer [][]rune, vert1, vert2, vert3, normal []float64) {
-	tempScreen := make([][]rune, len(buffer))
-	for i := range tempScreen {
-		tempScreen[i] = make([]rune, len(buffer[0]))
-		for j := range tempScreen[i] {
-			tempScreen[i][j] = ' '
-		}
-	}
-
-	projection := [][]float64{
-		{1, 0, 0},
-		{0, 1, 0},
-	}
-
-	shadingChars := []rune{'.', ',', '-', '~', ':', ';', '=', '!', '*', '#', '$', '@'}
-
-	lightDirection := []float64{0, 0, -1}
-	magnitude := math.Sqrt(lightDirection[0]*lightDirection[0] + lightDirection[1]*lightDirection[1] + lightDirection[2]*lightDirection[2])
-	lightDirection[0] /= magnitude
-	lightDirection[1] /= magnitude
-	lightDirection[2] /= magnitude
-
-	dot := normal[0]*lightDirection[0] + normal[1]*lightDirection[1] + normal[2]*lightDirection[2]
-	shadingChar := shadingChars[matrix.Clamp(dot*12, 0, len(shadingChars)-1)]
-
-	vert1 = r.getProjectedVertex(vert1, projection)
-	vert2 = r.getProjectedVertex(vert2, projection)
-	vert3 = r.getProjectedVertex(vert3, projection)
+func NewRender(matrix *matrix.Matrix) *Render {
+	r := &Render{
+		matrix: matrix,
+		screen: screen.NewScreen(matrix),
+		rotate: rotate.NewRotate(),
+		camera: camera.NewCamera(),
 
-	r.drawLineToBuffer(tempScreen, vert1[0], vert1[1], vert2[0], vert2[1], shadingChar)
-	r.drawLineToBuffer(tempScreen, vert2[0], vert2[1], vert3[0], vert3[1], shadingChar)
-	r.drawLineToBuffer(tempScreen, vert3[0], vert3[1], vert1[0], vert1[1], shadingChar)
+		orientation: rotate.IdentityQuaternion(),
+		spinAxis:    linalg.Vec3{X: 0, Y: 1, Z: 0},
 
-	r.fillTriangleArea(tempScreen, shadingChar)
+		ramp:          DefaultRamp,
+		materialRamps: make(map[string][]rune),
 
-	for i := 0; i < len(buffer); i++ {
-		for j := 0; j < len(buffer[0]); j++ {
-			if tempScreen[i][j] == shadingChar {
-				buffer[i][j] = tempScreen[i][j]
-			}
-		}
+		cellAspect: DefaultCellAspect,
 	}
+	r.cache = newRenderCache(DefaultCacheOptions())
+	return r
 }
 
-func (r *Render) getProjectedVertex(vertex []float64, projection [][]float64) []float64 {
-	key := [3]float64{vertex[0], vertex[1], vertex[2]}
+func (r *Render) SetDepthMode(mode DepthMode) {
+	r.depthMode = mode
+}
 
-	r.cacheMutex.RLock()
-	if cached, exists := r.projectionCache[key]; exists {
-		r.cacheMutex.RUnlock()
-		return cached
+func (r *Render) GetDepthMode() DepthMode {
+	return r.depthMode
+}
+
+// SetCellAspect sets the width of a terminal cell divided by its height
+// (see terminal.DetectCellAspect); zero or less restores DefaultCellAspect.
+func (r *Render) SetCellAspect(aspect float64) {
+	if aspect <= 0 {
+		aspect = DefaultCellAspect
 	}
-	r.cacheMutex.RUnlock()
+	r.cellAspect = aspect
+}
 
-	projected := convert.ToArray1D(matrix.MultiplyMatrices(projection, convert.ToArray2D(vertex)))
+func (r *Render) GetCellAspect() float64 {
+	return r.cellAspect
+}
 
-	r.cacheMutex.Lock()
-	r.projectionCache[key] = projected
-	r.cacheMutex.Unlock()
+// GetSize returns the size of the image in cells.
+func (r *Render) GetSize() (cols, rows int) {
+	return r.matrix.GetCols(), r.matrix.GetRows()
+}
 
-	return projected
+func (r *Render) SetCamera(c *camera.Camera) {
+	r.camera = c
 }
 
-func (r *Render) drawLineToBuffer(buffer [][]rune, x1, y1, x2, y2 float64, ch rune) {
-	x1 = float64(r.matrix.GetCols())/2.0 + x1/2.0*float64(r.matrix.GetCols())
-	y1 = float64(r.matrix.GetRows())/2.0 + y1/-2.0*float64(r.matrix.GetRows())
-	x2 = float64(r.matrix.GetCols())/2.0 + x2/2.0*float64(r.matrix.GetCols())
-	y2 = float64(r.matrix.GetRows())/2.0 + y2/-2.0*float64(r.matrix.GetRows())
+func (r *Render) GetCamera() *camera.Camera {
+	return r.camera
+}
 
-	d := 0
-	dx := int(math.Abs(x2 - x1))
-	dy := int(math.Abs(y2 - y1))
+// SetOrientation poses the model; the animation angle from the matrix is
+// applied on top of it as a spin around the spin axis.
+func (r *Render) SetOrientation(q rotate.Quaternion) {
+	r.orientation = q.Normalize()
+	r.resetRotationCache()
+}
 
-	dx2 := 2 * dx
-	dy2 := 2 * dy
+func (r *Render) GetOrientation() rotate.Quaternion {
+	return r.orientation
+}
 
-	ix := 1
-	if x1 > x2 {
-		ix = -1
-	}
-	iy := 1
-	if y1 > y2 {
-		iy = -1
-	}
+// SetSpinAxis sets the world-space axis the model turns around as the
+// matrix angle advances. A zero axis disables the spin.
+func (r *Render) SetSpinAxis(x, y, z float64) {
+	r.spinAxis = linalg.Vec3{X: x, Y: y, Z: z}
+	r.resetRotationCache()
+}
 
-	x := int(x1)
-	y := int(y1)
+func (r *Render) resetRotationCache() {
+	r.cache.rotations.Clear()
+	r.cache.frames.Clear()
+}
 
-	if dx >= dy {
-		for {
-			if y >= 0 && y < len(buffer) && x >= 0 && x < len(buffer[0]) && buffer[y][x] == ' ' {
-				buffer[y][x] = ch
-			}
-			if x == int(x2) {
-				break
-			}
-			x += ix
-			d += dy2
-			if d > dx {
-				y += iy
-				d -= dx2
-			}
-		}
-	} else {
-		for {
-			if y >= 0 && y < len(buffer) && x >= 0 && x < len(buffer[0]) && buffer[y][x] == ' ' {
-				buffer[y][x] = ch
-			}
-			if y == int(y2) {
-				break
-			}
-			y += iy
-			d += dx2
-			if d > dy {
-				x += ix
-				d -= dy2
-			}
-		}
-	}
+// SetOutput redirects the terminal output of Render, e.g. to a file or pipe,
+// and detects the color profile it supports.
+func (r *Render) SetOutput(w io.Writer) {
+	r.screen.SetOutput(w)
 }
 
-func (r *Render) renderThread() {
-	fps := 60
-	for {
-		start := time.Now()
-		r.screen.DrawScreen()
-		r.matrix.SetAngle(r.matrix.GetAngle() + 0.03*(60.0/float64(fps)))
+// SetColorProfile overrides the detected color support of the output;
+// termenv.Ascii draws characters only.
+func (r *Render) SetColorProfile(profile termenv.Profile) {
+	r.screen.SetColorProfile(profile)
+}
 
-		elapsed := time.Since(start)
-		sleepTime := time.Duration(1000/fps)*time.Millisecond - elapsed
-		if sleepTime > 0 {
-			time.Sleep(sleepTime)
-		}
-	}
+// SetFullRedraw makes the screen rewrite every frame completely instead of
+// sending only the cells that changed.
+func (r *Render) SetFullRedraw(full bool) {
+	r.screen.SetFullRedraw(full)
 }
 
-func (r *Render) updateRotation() {
-	angle := r.matrix.GetAngle()
+// DrawStats reports how many frames were drawn and how many bytes of
+// terminal output they took.
+func (r *Render) DrawStats() screen.DrawStats {
+	return r.screen.DrawStats()
+}
 
-	r.cacheMutex.RLock()
-	if cached, exists := r.rotationCache[angle]; exists {
-		r.rotate.Update(cached[0], cached[1], cached[2])
-		r.cacheMutex.RUnlock()
-		return
+// Render animates the model until ctx is cancelled or one of the limits in
+// opts is reached. It returns ctx.Err() on cancellation, nil when a limit
+// stopped the loop, and the first drawing error otherwise.
+func (r *Render) Render(ctx context.Context, model *mesh.Mesh, opts Options) error {
+	if err := checkModel(model); err != nil {
+		return err
 	}
-	r.cacheMutex.RUnlock()
-
-	xMatrix := [][]float64{
-		{1, 0, 0},
-		{0, 1, 0},
-		{0, 0, 1},
+	if opts.FPS <= 0 {
+		opts.FPS = DefaultFPS
 	}
-
-	yMatrix := [][]float64{
-		{math.Cos(angle), 0, math.Sin(angle)},
-		{0, 1, 0},
-		{-math.Sin(angle), 0, math.Cos(angle)},
+	if opts.Speed == 0 {
+		opts.Speed = DefaultSpeed
 	}
 
-	zMatrix := [][]float64{
-		{1, 0, 0},
-		{0, 1, 0},
-		{0, 0, 1},
+	restore := func() {}
+	if opts.FullScreen {
+		session, err := terminal.Enter(r.screen.GetOutput())
+		if err != nil {
+			return fmt.Errorf("entering full screen: %w", err)
+		}
+		restore = session.Restore
+		defer restore()
 	}
 
-	r.cacheMutex.Lock()
-	r.rotationCache[angle] = [][][]float64{xMatrix, yMatrix, zMatrix}
-	r.cacheMutex.Unlock()
-
-	r.rotate.Update(xMatrix, yMatrix, zMatrix)
-}
-
-func (r *Render) processVertices(verts [][]float64) [][]float64 {
-	angle := r.matrix.GetAngle()
-
-	r.cacheMutex.RLock()
-	if cached, exists := r.transformedVerts[angle]; exists {
-		r.cacheMutex.RUnlock()
-		return cached
+	loopCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if opts.Duration > 0 {
+		loopCtx, cancel = context.WithTimeout(loopCtx, opts.Duration)
+		defer cancel()
 	}
-	r.cacheMutex.RUnlock()
-
-	var visibleVerts [][]float64
 
-	for i := 0; i < len(verts); i += 3 {
-		if i+2 >= len(verts) {
-			break
+	frames := make(chan struct{}, 1)
+	drawErr := make(chan error, 1)
+	go func() {
+		// A panic here ends the program without running Render's defers.
+		defer func() {
+			if p := recover(); p != nil {
+				restore()
+				panic(p)
+			}
+		}()
+		drawErr <- r.renderThread(loopCtx, frames, opts.Frames)
+		cancel()
+	}()
+
+	ticker := time.NewTicker(time.Second / time.Duration(opts.FPS))
+	defer ticker.Stop()
+
+	// The render loop is the only writer of the angle; it is derived from
+	// the time elapsed since start so the spin speed does not depend on
+	// how fast frames are produced or drawn.
+	start := time.Now()
+	startAngle := r.matrix.GetAngle()
+
+	for loopCtx.Err() == nil {
+		select {
+		case size := <-opts.Resize:
+			r.resize(size.Fit())
+		default:
 		}
 
-		vert1 := convert.ToArray1D(matrix.MultiplyMatrices(r.rotate.GetX(), matrix.MultiplyMatrices(r.rotate.GetY(), matrix.MultiplyMatrices(r.rotate.GetZ(), convert.ToArray2D(verts[i])))))
-		vert2 := convert.ToArray1D(matrix.MultiplyMatrices(r.rotate.GetX(), matrix.MultiplyMatrices(r.rotate.GetY(), matrix.MultiplyMatrices(r.rotate.GetZ(), convert.ToArray2D(verts[i+1])))))
-		vert3 := convert.ToArray1D(matrix.MultiplyMatrices(r.rotate.GetX(), matrix.MultiplyMatrices(r.rotate.GetY(), matrix.MultiplyMatrices(r.rotate.GetZ(), convert.ToArray2D(verts[i+2])))))
+		r.matrix.SetAngle(startAngle + opts.Speed*time.Since(start).Seconds())
+		r.renderFrame(model)
 
-		normal := r.calculateNormal(vert1, vert2, vert3)
+		select {
+		case frames <- struct{}{}:
+		default:
+		}
 
-		if normal[0]*vert1[0]+normal[1]*vert1[1]+normal[2]*(vert1[2]-10) > 1 {
-			visibleVerts = append(visibleVerts, vert1, vert2, vert3, normal)
+		select {
+		case <-loopCtx.Done():
+		case <-ticker.C:
 		}
 	}
 
-	r.cacheMutex.Lock()
-	r.transformedVerts[angle] = visibleVerts
-	r.cacheMutex.Unlock()
-
-	return visibleVerts
+	if err := <-drawErr; err != nil {
+		return err
+	}
+	return ctx.Err()
 }
 
-func (r *Render) calculateNormal(vert1, vert2, vert3 []float64) []float64 {
-	key := [3]float64{
-		vert1[0] + vert2[0] + vert3[0],
-		vert1[1] + vert2[1] + vert3[1],
-		vert1[2] + vert2[2] + vert3[2],
+// checkModel rejects models with nothing to draw. A model without faces is
+// drawn as points.
+func checkModel(model *mesh.Mesh) error {
+	if model == nil || len(model.Positions) == 0 {
+		return errors.New("render: model has no vertices")
 	}
+	return nil
+}
 
-	r.cacheMutex.RLock()
-	if cached, exists := r.normalCache[key]; exists {
-		r.cacheMutex.RUnlock()
-		return cached
+// resize reallocates the matrix for a cols x rows image; the canvas and the
+// projection follow on the next frame. Sizes below one cell are ignored.
+func (r *Render) resize(cols, rows int) {
+	if cols < 1 || rows < 1 {
+		return
 	}
-	r.cacheMutex.RUnlock()
+	r.matrix.Resize(cols, rows)
+}
 
-	normal := []float64{
-		((vert2[1] - vert1[1]) * (vert3[2] - vert1[2])) - ((vert2[2] - vert1[2]) * (vert3[1] - vert1[1])),
-		((vert2[2] - vert1[2]) * (vert3[0] - vert1[0])) - ((vert2[0] - vert1[0]) * (vert3[2] - vert1[2])),
-		((vert2[0] - vert1[0]) * (vert3[1] - vert1[1])) - ((vert2[1] - vert1[1]) * (vert3[0] - vert1[0])),
-	}
+// renderFrame draws the model at the current angle into the matrix back
+// buffer and presents it.
+func (r *Render) renderFrame(model *mesh.Mesh) {
+	r.updateCamera()
+	r.updateRotation()
+	trianglesToRender := r.orderTriangles(r.processVertices(model))
 
-	magnitude := math.Sqrt(normal[0]*normal[0] + normal[1]*normal[1] + normal[2]*normal[2])
-	if magnitude > 0 {
-		normal[0] /= magnitude
-		normal[1] /= magnitude
-		normal[2] /= magnitude
-	}
+	back := r.matrix.Back()
+	r.screen.InitScreen(back)
+	r.matrix.ClearDepth()
 
-	r.cacheMutex.Lock()
-	r.normalCache[key] = normal
-	r.cacheMutex.Unlock()
+	r.drawTriangles(back, r.matrix.DepthBuffer, trianglesToRender, model)
 
-	return normal
+	r.matrix.Present()
 }
 
-func (r *Render) fillTriangle(vert1, vert2, vert3, normal []float64) {
-	tempScreen := make([][]rune, len(r.matrix.ScreenBuffer[0]))
-	for i := range tempScreen {
-		tempScreen[i] = make([]rune, len(r.matrix.ScreenBuffer[0][0]))
+// RenderFrontFace renders the model at angle 0, in the orientation set with
+// SetOrientation, and returns its characters; see RenderFrame.
+func (r *Render) RenderFrontFace(model *mesh.Mesh) string {
+	frame, err := r.RenderFrame(model, r.orientation, nil)
+	if err != nil {
+		return ""
 	}
-	r.screen.InitScreen(tempScreen)
+	return frame.String()
+}
 
-	projection := [][]float64{
-		{1, 0, 0},
-		{0, 1, 0},
+// orderTriangles sorts triangles back to front in painter mode; with a depth
+// buffer the draw order does not matter and sorting is skipped.
+func (r *Render) orderTriangles(triangles []matrix.Triangle) []matrix.Triangle {
+	if r.depthMode == DepthPainter {
+		return r.matrix.SortVerts(triangles)
 	}
+	return triangles
+}
 
-	shadingChars := []rune{'.', ',', '-', '~', ':', ';', '=', '!', '*', '#', '$', '@'}
+// fillTriangle rasterizes one visible triangle into target, plotting every
+// fragment that passes the target's depth test.
+func (r *Render) fillTriangle(target surface, triangle *matrix.Triangle, material *mesh.Material) {
+	lightDirection := r.camera.Forward().Neg().Normalize()
+	ramp := r.rampFor(material)
+
+	// Flat faces without a texture or vertex colors have a single color;
+	// compute it once.
+	perFragment := triangle.Smooth || (triangle.Textured && material.Texture != nil) || triangle.Colored
+	flatCell := shadeCell(ramp, lightColor(material, material.Diffuse, triangle.Normal, lightDirection))
+
+	cols, rows := target.Size()
+	v0 := toRaster(triangle.Projected[0], cols, rows)
+	v1 := toRaster(triangle.Projected[1], cols, rows)
+	v2 := toRaster(triangle.Projected[2], cols, rows)
+
+	raster.Triangle(cols, rows, v0, v1, v2, func(f raster.Fragment) {
+		if dissolved(material.Dissolve, f.X, f.Y) {
+			return
+		}
+		if !target.DepthTest(f.X, f.Y, f.Depth) {
+			return
+		}
+		if !perFragment {
+			target.Plot(f.X, f.Y, flatCell)
+			return
+		}
 
-	lightDirection := []float64{0, 0, -1}
-	magnitude := math.Sqrt(lightDirection[0]*lightDirection[0] + lightDirection[1]*lightDirection[1] + lightDirection[2]*lightDirection[2])
-	lightDirection[0] /= magnitude
-	lightDirection[1] /= magnitude
-	lightDirection[2] /= magnitude
+		normal := triangle.Normal
+		if triangle.Smooth {
+			normal = triangle.Normals[0].Scale(f.Weights[0]).
+				Add(triangle.Normals[1].Scale(f.Weights[1])).
+				Add(triangle.Normals[2].Scale(f.Weights[2])).
+				Normalize()
+		}
+		diffuse := material.Diffuse
+		if triangle.Textured {
+			uv := triangle.UVs[0].Scale(f.Weights[0]).
+				Add(triangle.UVs[1].Scale(f.Weights[1])).
+				Add(triangle.UVs[2].Scale(f.Weights[2]))
+			diffuse = material.SampleDiffuse(uv)
+		}
+		tint := white
+		if triangle.Colored {
+			tint = triangle.Colors[0].Scale(f.Weights[0]).
+				Add(triangle.Colors[1].Scale(f.Weights[1])).
+				Add(triangle.Colors[2].Scale(f.Weights[2]))
+		}
+		target.Plot(f.X, f.Y, shadeTinted(ramp, material, diffuse, tint, normal, lightDirection))
+	})
+}
 
-	dot := normal[0]*lightDirection[0] + normal[1]*lightDirection[1] + normal[2]*lightDirection[2]
-	shadingChar := shadingChars[matrix.Clamp(dot*12, 0, len(shadingChars)-1)]
+// toRaster maps a vertex in normalized device coordinates to screen cells.
+func toRaster(vertex linalg.Vec3, cols, rows int) raster.Vertex {
+	x, y := camera.Viewport(vertex.X, vertex.Y, cols, rows)
+	return raster.Vertex{X: x, Y: y, Z: vertex.Z}
+}
 
-	vert1 = r.getProjectedVertex(vert1, projection)
-	vert2 = r.getProjectedVertex(vert2, projection)
-	vert3 = r.getProjectedVertex(vert3, projection)
+func (r *Render) getProjectedVertex(vertex linalg.Vec3) linalg.Vec3 {
+	if cached, exists := r.cache.projections.Get(vertex); exists {
+		return cached
+	}
 
-	r.drawLine(tempScreen, vert1[0], vert1[1], vert2[0], vert2[1], shadingChar)
-	r.drawLine(tempScreen, vert2[0], vert2[1], vert3[0], vert3[1], shadingChar)
-	r.drawLine(tempScreen, vert3[0], vert3[1], vert1[0], vert1[1], shadingChar)
+	projected := camera.Project(r.viewProjection, vertex)
+	r.cache.projections.Put(vertex, projected)
 
-	r.fillTriangleArea(tempScreen, shadingChar)
+	return projected
+}
 
-	for i := 0; i < len(r.matrix.ScreenBuffer[0]); i++ {
-		for j := 0; j < len(r.matrix.ScreenBuffer[0][0]); j++ {
-			if tempScreen[i][j] == shadingChar {
-				r.matrix.ScreenBuffer[0][i][j] = tempScreen[i][j]
-			}
+// renderThread draws every frame signalled on frames until ctx is done or
+// limit frames have been drawn; a zero limit means no limit.
+func (r *Render) renderThread(ctx context.Context, frames <-chan struct{}, limit int) error {
+	for drawn := 0; limit == 0 || drawn < limit; drawn++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-frames:
+		}
+
+		if err := r.screen.DrawScreen(); err != nil {
+			return fmt.Errorf("drawing frame %d: %w", drawn, err)
 		}
 	}
+	return nil
 }
 
-func (r *Render) drawLine(screen [][]rune, x1, y1, x2, y2 float64, ch rune) {
-	x1 = float64(r.matrix.GetCols())/2.0 + x1/2.0*float64(r.matrix.GetCols())
-	y1 = float64(r.matrix.GetRows())/2.0 + y1/-2.0*float64(r.matrix.GetRows())
-	x2 = float64(r.matrix.GetCols())/2.0 + x2/2.0*float64(r.matrix.GetCols())
-	y2 = float64(r.matrix.GetRows())/2.0 + y2/-2.0*float64(r.matrix.GetRows())
+// updateCamera recomputes the view-projection matrix for the current camera
+// and drops every cached result that depended on the previous one.
+//
+// The viewport stretches normalized coordinates over the whole raster grid,
+// so the projection has to use the physical shape of that grid: its size
+// in pixels times the width-to-height ratio of one pixel.
+func (r *Render) updateCamera() {
+	width, height := r.rasterSize()
+	cellWidth, cellHeight := r.pixelMode.CellSize()
+	pixelAspect := r.cellAspect * float64(cellHeight) / float64(cellWidth)
+	aspect := float64(width) / float64(height) * pixelAspect
+	viewProjection := r.camera.ViewProjection(aspect)
+
+	if viewProjection == r.viewProjection {
+		return
+	}
 
-	d := 0
-	dx := int(math.Abs(x2 - x1))
-	dy := int(math.Abs(y2 - y1))
+	r.cache.frames.Clear()
+	r.cache.projections.Clear()
 
-	dx2 := 2 * dx
-	dy2 := 2 * dy
+	r.viewProjection = viewProjection
+}
 
-	ix := 1
-	if x1 > x2 {
-		ix = -1
-	}
-	iy := 1
-	if y1 > y2 {
-		iy = -1
+func (r *Render) updateRotation() {
+	key := angleKey(r.matrix.GetAngle())
+
+	if cached, exists := r.cache.rotations.Get(key); exists {
+		r.rotate.Set(cached)
+		return
 	}
 
-	x := int(x1)
-	y := int(y1)
+	spin := rotate.FromAxisAngle(r.spinAxis.X, r.spinAxis.Y, r.spinAxis.Z, float64(key)*AngleStep)
+	orientation := spin.Mul(r.orientation)
+	r.cache.rotations.Put(key, orientation)
 
-	if dx >= dy {
-		for {
-			if y >= 0 && y < len(screen) && x >= 0 && x < len(screen[0]) && screen[y][x] == ' ' {
-				screen[y][x] = ch
-			}
-			if x == int(x2) {
-				break
-			}
-			x += ix
-			d += dy2
-			if d > dx {
-				y += iy
-				d -= dx2
-			}
-		}
-	} else {
-		for {
-			if y >= 0 && y < len(screen) && x >= 0 && x < len(screen[0]) && screen[y][x] == ' ' {
-				screen[y][x] = ch
-			}
-			if y == int(y2) {
-				break
-			}
-			y += iy
-			d += dx2
-			if d > dy {
-				x += ix
-				d -= dy2
-			}
-		}
-	}
+	r.rotate.Set(orientation)
 }
 
-func (r *Render) fillTriangleArea(screen [][]rune, shadingChar rune) {
-	for row := 0; row < len(screen); row++ {
-		rowStr := string(screen[row])
-		shadingStr := string(shadingChar)
-		firstIndex := strings.Index(rowStr, shadingStr)
-		lastIndex := strings.LastIndex(rowStr, shadingStr)
+// processVertices returns the visible triangles at the current angle,
+// cached per quantized angle for the model last drawn; drawing another
+// model drops the cached triangles of the previous one.
+func (r *Render) processVertices(model *mesh.Mesh) []matrix.Triangle {
+	if model != r.cache.model {
+		r.cache.frames.Clear()
+		r.cache.model = model
+	}
+	key := angleKey(r.matrix.GetAngle())
 
-		if firstIndex != -1 && lastIndex != -1 && firstIndex < lastIndex {
-			for i := firstIndex; i < lastIndex; i++ {
-				screen[row][i] = shadingChar
-			}
-		}
+	if cached, exists := r.cache.frames.Get(key); exists {
+		return cached
 	}
-}
 
-func (r *Render) ClearCache() {
-	r.cacheMutex.Lock()
-	defer r.cacheMutex.Unlock()
+	visible := r.transformTriangles(model, r.rotate.Matrix())
+	r.cache.frames.Put(key, visible)
 
-	r.rotationCache = make(map[float64][][][]float64)
-	r.transformedVerts = make(map[float64][][]float64)
-	r.projectionCache = make(map[[3]float64][]float64)
-	r.normalCache = make(map[[3]float64][]float64)
+	return visible
 }
 
-func LoadOBJ(filename string) ([][]float64, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+// transformTriangles rotates every face of the model, drops the ones that
+// face away from the camera, clips the rest to the clip range (see
+// clipTriangle) and projects them.
+func (r *Render) transformTriangles(model *mesh.Mesh, rotation linalg.Mat3) []matrix.Triangle {
+	visible := make([]matrix.Triangle, 0, len(model.Faces))
+
+	for i, face := range model.Faces {
+		v1, v2, v3 := model.Triangle(i)
+		vert1 := rotation.MulVec(v1)
+		vert2 := rotation.MulVec(v2)
+		vert3 := rotation.MulVec(v3)
 
-	var verts [][]float64
-	var faces []int
+		normal := r.calculateNormal(vert1, vert2, vert3)
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if len(line) == 0 || line[0] == '#' {
+		if !r.camera.IsFrontFacing(vert1, normal) {
 			continue
 		}
 
-		if line[0] == 'v' && line[1] == ' ' {
-			parts := strings.Fields(line)
-			if len(parts) >= 4 {
-				x, _ := strconv.ParseFloat(parts[1], 64)
-				y, _ := strconv.ParseFloat(parts[2], 64)
-				z, _ := strconv.ParseFloat(parts[3], 64)
-				verts = append(verts, []float64{x, y, z})
+		triangle := matrix.Triangle{
+			Verts:    [3]linalg.Vec3{vert1, vert2, vert3},
+			Normal:   normal,
+			Material: face.Material,
+		}
+		if model.HasNormals(i) && face.Smooth != 0 {
+			triangle.Smooth = true
+			for c := 0; c < 3; c++ {
+				triangle.Normals[c] = rotation.MulVec(model.Normals[face.Normals[c]])
 			}
-		} else if line[0] == 'f' {
-			parts := strings.Fields(line)
-			if len(parts) >= 4 {
-				for i := 1; i <= 3; i++ {
-					vertexPart := parts[i]
-					vertexIndexParts := strings.Split(vertexPart, "/")
-					indexStr := vertexIndexParts[0]
-
-					index, err := strconv.Atoi(indexStr)
-					if err != nil {
-						continue
-					}
-
-					if index < 0 {
-						index = len(verts) + index
-					} else {
-						index = index - 1
-					}
-
-					if index >= 0 && index < len(verts) {
-						faces = append(faces, index)
-					}
-				}
+		}
+		if model.HasUVs(i) {
+			triangle.Textured = true
+			for c := 0; c < 3; c++ {
+				triangle.UVs[c] = model.UVs[face.UVs[c]]
+			}
+		}
+		if model.HasColors() {
+			triangle.Colored = true
+			for c := 0; c < 3; c++ {
+				triangle.Colors[c] = model.Colors[face.Positions[c]]
 			}
 		}
-	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+		if !r.inClipRange(&triangle) {
+			visible = r.clipTriangle(visible, triangle)
+			continue
+		}
+		for c, vertex := range triangle.Verts {
+			triangle.Projected[c] = r.getProjectedVertex(vertex)
+		}
+		visible = append(visible, triangle)
 	}
+	return visible
+}
 
-	result := make([][]float64, len(faces))
-	for i := 0; i < len(faces); i++ {
-		if faces[i] >= 0 && faces[i] < len(verts) {
-			result[i] = verts[faces[i]]
-		} else {
-			result[i] = []float64{0, 0, 0}
-		}
+// calculateNormal returns the unit normal of a triangle, facing the side
+// its vertices wind counterclockwise around. The cache key keeps the
+// vertex order, since the same corners wound the other way face away.
+func (r *Render) calculateNormal(vert1, vert2, vert3 linalg.Vec3) linalg.Vec3 {
+	key := [3]linalg.Vec3{vert1, vert2, vert3}
+
+	if cached, exists := r.cache.normals.Get(key); exists {
+		return cached
 	}
 
-	return result, nil
+	normal := vert2.Sub(vert1).Cross(vert3.Sub(vert1)).Normalize()
+	r.cache.normals.Put(key, normal)
+
+	return normal
+}
+
+// LoadOBJ reads a Wavefront OBJ model; see mesh.LoadOBJ.
+func LoadOBJ(filename string) (*mesh.Mesh, error) {
+	return mesh.LoadOBJ(filename)
+}
+
+// LoadModel reads a model in any supported format; see mesh.Load.
+func LoadModel(filename string) (*mesh.Mesh, error) {
+	return mesh.Load(filename)
 }
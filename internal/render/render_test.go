@@ -0,0 +1,289 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/muesli/termenv"
+
+	"zontengine/internal/linalg"
+	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+	"zontengine/internal/rotate"
+	"zontengine/internal/terminal"
+)
+
+// tetrahedron returns a small closed model centered on the origin.
+func tetrahedron() *mesh.Mesh {
+	model := &mesh.Mesh{
+		Name: "tetrahedron",
+		Positions: []linalg.Vec3{
+			{X: 1, Y: 1, Z: 1},
+			{X: -1, Y: -1, Z: 1},
+			{X: -1, Y: 1, Z: -1},
+			{X: 1, Y: -1, Z: -1},
+		},
+	}
+	for _, corners := range [][3]int{{0, 1, 2}, {0, 3, 1}, {0, 2, 3}, {1, 3, 2}} {
+		model.Faces = append(model.Faces, mesh.Face{
+			Positions: corners,
+			Normals:   [3]int{-1, -1, -1},
+			UVs:       [3]int{-1, -1, -1},
+			Material:  -1,
+		})
+	}
+	return model
+}
+
+// card returns two parallel squares back to back: a red one half a unit
+// towards -Z facing -Z and a blue one half a unit towards +Z facing +Z.
+// Culling leaves one of them visible from any side.
+func card() *mesh.Mesh {
+	model := &mesh.Mesh{Name: "card"}
+	square := [4][2]float64{{-1, -1}, {-1, 1}, {1, 1}, {1, -1}}
+	for _, side := range []struct {
+		z     float64
+		color linalg.Vec3
+	}{
+		{z: -0.5, color: linalg.Vec3{X: 1}},
+		{z: 0.5, color: linalg.Vec3{Z: 1}},
+	} {
+		base := len(model.Positions)
+		for _, corner := range square {
+			x := corner[0]
+			if side.z > 0 {
+				// Mirroring turns the winding around.
+				x = -x
+			}
+			model.Positions = append(model.Positions, linalg.Vec3{X: x, Y: corner[1], Z: side.z})
+			model.Colors = append(model.Colors, side.color)
+		}
+		for _, corners := range [][3]int{{0, 1, 2}, {0, 2, 3}} {
+			model.Faces = append(model.Faces, mesh.Face{
+				Positions: [3]int{base + corners[0], base + corners[1], base + corners[2]},
+				Normals:   [3]int{-1, -1, -1},
+				UVs:       [3]int{-1, -1, -1},
+				Material:  -1,
+			})
+		}
+	}
+	return model
+}
+
+func newTestRender(cols, rows int) (*Render, *bytes.Buffer) {
+	r := NewRender(matrix.NewMatrix(cols, rows))
+	var out bytes.Buffer
+	r.SetOutput(&out)
+	r.SetColorProfile(termenv.Ascii)
+	return r, &out
+}
+
+func TestRenderLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		frames     int // exact number of frames drawn, 0 for any
+		minElapsed time.Duration
+	}{
+		{name: "frames", opts: Options{FPS: 500, Frames: 5}, frames: 5},
+		{name: "one frame", opts: Options{FPS: 500, Frames: 1}, frames: 1},
+		{name: "duration", opts: Options{FPS: 200, Duration: 50 * time.Millisecond}, minElapsed: 50 * time.Millisecond},
+		{name: "frames before duration", opts: Options{FPS: 500, Frames: 3, Duration: time.Minute}, frames: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, out := newTestRender(24, 8)
+
+			start := time.Now()
+			if err := r.Render(context.Background(), tetrahedron(), tt.opts); err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			elapsed := time.Since(start)
+
+			stats := r.DrawStats()
+			if tt.frames > 0 && stats.Frames != tt.frames {
+				t.Errorf("drew %d frames, want %d", stats.Frames, tt.frames)
+			}
+			if stats.Frames == 0 || out.Len() == 0 {
+				t.Errorf("drew %d frames into %d bytes, want some output", stats.Frames, out.Len())
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("returned after %v, want at least %v", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestRenderCancel(t *testing.T) {
+	r, _ := newTestRender(24, 8)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	err := r.Render(ctx, tetrahedron(), Options{FPS: 200})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Render = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRenderResize(t *testing.T) {
+	sizes := []terminal.Size{
+		{Cols: 40, Rows: 13},
+		{Cols: 0, Rows: 10}, // too small, ignored
+		{Cols: 16, Rows: 7},
+		{Cols: 30, Rows: 11},
+	}
+
+	r, _ := newTestRender(24, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resize := make(chan terminal.Size)
+	go func() {
+		// Each send is taken by the render loop, which resizes before the
+		// next frame; cancelling after the last one stops Render.
+		defer cancel()
+		for _, size := range sizes {
+			select {
+			case resize <- size:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err := r.Render(ctx, tetrahedron(), Options{FPS: 500, Resize: resize})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Render = %v, want %v", err, context.Canceled)
+	}
+
+	if cols, rows := r.GetSize(); cols != 30 || rows != 10 {
+		t.Errorf("size after resizing is %dx%d, want 30x10", cols, rows)
+	}
+	if r.DrawStats().Frames == 0 {
+		t.Error("no frame was drawn")
+	}
+}
+
+func TestDefaultCameraSide(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation rotate.Quaternion
+		side        string
+	}{
+		{name: "unrotated", orientation: rotate.IdentityQuaternion(), side: "red"},
+		{name: "half turn", orientation: rotate.FromAxisAngle(0, 1, 0, math.Pi), side: "blue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestRender(32, 16)
+			frame, err := r.RenderFrame(card(), tt.orientation, nil)
+			if err != nil {
+				t.Fatalf("RenderFrame: %v", err)
+			}
+
+			covered := 0
+			for row := range frame.Cells {
+				for col, cell := range frame.Cells[row] {
+					if !frame.Covered(col, row) {
+						continue
+					}
+					covered++
+					side := "blue"
+					if red, _, blue := cell.Fg.RGB(); red > blue {
+						side = "red"
+					}
+					if side != tt.side {
+						t.Fatalf("cell (%d, %d) is %s, want the %s side", col, row, cell.Fg.Hex(), tt.side)
+					}
+				}
+			}
+			if covered == 0 {
+				t.Fatal("nothing drawn")
+			}
+		})
+	}
+}
+
+func TestNearPlaneClipping(t *testing.T) {
+	// A floor under the camera, running from behind it to far in front:
+	// every triangle crosses the near plane.
+	floor := &mesh.Mesh{
+		Name: "floor",
+		Positions: []linalg.Vec3{
+			{X: -1, Y: -0.5, Z: -4},
+			{X: -1, Y: -0.5, Z: 2},
+			{X: 1, Y: -0.5, Z: 2},
+			{X: 1, Y: -0.5, Z: -4},
+		},
+	}
+	for _, corners := range [][3]int{{0, 1, 2}, {0, 2, 3}} {
+		floor.Faces = append(floor.Faces, mesh.Face{
+			Positions: corners,
+			Normals:   [3]int{-1, -1, -1},
+			UVs:       [3]int{-1, -1, -1},
+			Material:  -1,
+		})
+	}
+
+	r, _ := newTestRender(32, 16)
+	frame, err := r.RenderFrame(floor, rotate.IdentityQuaternion(), nil)
+	if err != nil {
+		t.Fatalf("RenderFrame: %v", err)
+	}
+
+	// The floor reaches the bottom edge of the view below the camera and
+	// never rises above the horizon.
+	if !frame.Covered(frame.Cols/2, frame.Rows-1) {
+		t.Errorf("floor missing below the camera:\n%s", frame)
+	}
+	for row := 0; row < frame.Rows/2; row++ {
+		for col := 0; col < frame.Cols; col++ {
+			if frame.Covered(col, row) {
+				t.Fatalf("cell (%d, %d) above the horizon covered:\n%s", col, row, frame)
+			}
+		}
+	}
+	for row := range frame.Depth {
+		for col, depth := range frame.Depth[row] {
+			if frame.Covered(col, row) && (depth < -1 || depth > 1) {
+				t.Fatalf("cell (%d, %d) has depth %v outside the clip range", col, row, depth)
+			}
+		}
+	}
+}
+
+func TestSmoothingGroups(t *testing.T) {
+	model := tetrahedron()
+	for _, position := range model.Positions {
+		model.Normals = append(model.Normals, position.Normalize())
+	}
+	for i := range model.Faces {
+		model.Faces[i].Normals = model.Faces[i].Positions
+		model.Faces[i].Smooth = i % 2
+		// The model has no materials, so the index only tells the faces
+		// apart.
+		model.Faces[i].Material = i
+	}
+
+	r, _ := newTestRender(24, 8)
+	r.updateCamera()
+	seen := make(map[int]bool)
+	for _, rotation := range []linalg.Mat3{linalg.Identity3(), rotate.RotationY(math.Pi)} {
+		for _, triangle := range r.transformTriangles(model, rotation) {
+			face := model.Faces[triangle.Material]
+			if want := face.Smooth != 0; triangle.Smooth != want {
+				t.Errorf("face %d in smoothing group %d drawn with Smooth %v", triangle.Material, face.Smooth, triangle.Smooth)
+			}
+			seen[face.Smooth] = true
+		}
+	}
+	if !seen[0] || !seen[1] {
+		t.Fatal("not every smoothing group was visible")
+	}
+}
@@ -0,0 +1,123 @@
+package render
+
+/**
+ * Lighting and character selection for rasterized fragments.
+ *
+ * Surfaces are lit by a single white light at the camera (a headlight) with
+ * a small ambient term, using the material's Ka, Kd (optionally textured)
+ * and Ks/Ns. The resulting color's luminance picks a character from the
+ * shading ramp, which may be overridden per material. Materials with a
+ * dissolve below 1 are drawn with an ordered dither so that part of what is
+ * behind them shows through. The lit color itself becomes the cell's
+ * foreground color.
+ *
+ * Vertex colors tint the diffuse color of the cell's foreground only; the
+ * character is still chosen from the untinted light, so a colored model
+ * looks the same as an uncolored one when colors are not shown, and its
+ * colors add to the shading when they are.
+ */
+
+import (
+	"math"
+	"zontengine/internal/linalg"
+	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+)
+
+// DefaultRamp orders the shading characters from darkest to brightest.
+var DefaultRamp = []rune{'.', ',', '-', '~', ':', ';', '=', '!', '*', '#', '$', '@'}
+
+// ambientLight scales a material's Ka; exporters often write Ka 1 1 1.
+const ambientLight = 0.1
+
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// SetShadingRamp replaces the characters used for materials without their
+// own ramp, ordered from darkest to brightest.
+func (r *Render) SetShadingRamp(ramp []rune) {
+	if len(ramp) > 0 {
+		r.ramp = ramp
+	}
+}
+
+// SetMaterialRamp uses ramp for faces with the named material; an empty
+// ramp removes the override.
+func (r *Render) SetMaterialRamp(material string, ramp []rune) {
+	if len(ramp) == 0 {
+		delete(r.materialRamps, material)
+		return
+	}
+	r.materialRamps[material] = ramp
+}
+
+func (r *Render) rampFor(material *mesh.Material) []rune {
+	if ramp, exists := r.materialRamps[material.Name]; exists {
+		return ramp
+	}
+	return r.ramp
+}
+
+// lightColor returns the color of a surface point with unit normal, lit
+// from lightDirection, whose diffuse color is diffuse.
+func lightColor(material *mesh.Material, diffuse, normal, lightDirection linalg.Vec3) linalg.Vec3 {
+	dot := math.Max(0, normal.Dot(lightDirection))
+
+	color := material.Ambient.Scale(ambientLight).Add(diffuse.Scale(dot))
+	if material.Shininess > 0 {
+		color = color.Add(material.Specular.Scale(math.Pow(dot, material.Shininess)))
+	}
+	return color
+}
+
+func luminance(color linalg.Vec3) float64 {
+	return 0.2126*color.X + 0.7152*color.Y + 0.0722*color.Z
+}
+
+// shadeCell returns the cell for a surface point of the given lit color.
+func shadeCell(ramp []rune, color linalg.Vec3) matrix.Cell {
+	return matrix.Cell{
+		Ch: rampChar(ramp, luminance(color)),
+		Fg: matrix.ColorFromVec3(color),
+	}
+}
+
+// white is the tint of surfaces without vertex colors.
+var white = linalg.Vec3{X: 1, Y: 1, Z: 1}
+
+// shadeTinted returns the cell for a surface point whose diffuse color is
+// modulated by tint in the foreground color; see the package comment.
+func shadeTinted(ramp []rune, material *mesh.Material, diffuse, tint, normal, lightDirection linalg.Vec3) matrix.Cell {
+	cell := shadeCell(ramp, lightColor(material, diffuse, normal, lightDirection))
+	if tint != white {
+		cell.Fg = matrix.ColorFromVec3(lightColor(material, diffuse.Mul(tint), normal, lightDirection))
+	}
+	return cell
+}
+
+// flatTint returns the average vertex color of a triangle, white when it
+// has none.
+func flatTint(triangle *matrix.Triangle) linalg.Vec3 {
+	if !triangle.Colored {
+		return white
+	}
+	return triangle.Colors[0].Add(triangle.Colors[1]).Add(triangle.Colors[2]).Scale(1.0 / 3)
+}
+
+// rampChar maps an intensity in [0, 1] to a character of ramp.
+func rampChar(ramp []rune, intensity float64) rune {
+	return ramp[matrix.Clamp(intensity*float64(len(ramp)), 0, len(ramp)-1)]
+}
+
+// dissolved reports whether the cell at (x, y) should be skipped for a
+// material with the given opacity.
+func dissolved(opacity float64, x, y int) bool {
+	if opacity >= 1 {
+		return false
+	}
+	return opacity*16 <= bayer4[y&3][x&3]
+}
@@ -0,0 +1,127 @@
+package render
+
+/**
+ * Wireframe and point cloud drawing.
+ *
+ * Both styles write through the same surfaces as solid triangles, so they
+ * combine with every pixel mode; canvas.Braille gives the finest lines.
+ * Wireframes stroke the edges of the front-facing triangles in their flat
+ * lit color. Point clouds plot every vertex of the model, brighter the
+ * closer it is to the camera and tinted by its vertex color; models without
+ * faces are always drawn this way.
+ */
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"zontengine/internal/linalg"
+	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+	"zontengine/internal/raster"
+)
+
+// DrawStyle selects what is drawn of the model.
+type DrawStyle int
+
+const (
+	// Solid fills and shades the visible triangles.
+	Solid DrawStyle = iota
+	// Wireframe draws the edges of the visible triangles.
+	Wireframe
+	// Points draws every vertex of the model.
+	Points
+)
+
+func (s DrawStyle) String() string {
+	switch s {
+	case Wireframe:
+		return "wireframe"
+	case Points:
+		return "points"
+	}
+	return "solid"
+}
+
+// ParseDrawStyle converts the name of a style as returned by String.
+func ParseDrawStyle(name string) (DrawStyle, error) {
+	for _, style := range []DrawStyle{Solid, Wireframe, Points} {
+		if strings.EqualFold(name, style.String()) {
+			return style, nil
+		}
+	}
+	return Solid, fmt.Errorf("unknown draw style %q (want solid, wireframe or points)", name)
+}
+
+func (r *Render) SetDrawStyle(style DrawStyle) {
+	r.drawStyle = style
+}
+
+func (r *Render) GetDrawStyle() DrawStyle {
+	return r.drawStyle
+}
+
+// strokeTriangle draws the three edges of a visible triangle into target.
+func (r *Render) strokeTriangle(target surface, triangle *matrix.Triangle, material *mesh.Material) {
+	lightDirection := r.camera.Forward().Neg().Normalize()
+	cell := shadeTinted(r.rampFor(material), material, material.Diffuse, flatTint(triangle), triangle.Normal, lightDirection)
+
+	cols, rows := target.Size()
+	plot := func(f raster.Fragment) {
+		if target.DepthTest(f.X, f.Y, f.Depth) {
+			target.Plot(f.X, f.Y, cell)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		a := toRaster(triangle.Projected[i], cols, rows)
+		b := toRaster(triangle.Projected[(i+1)%3], cols, rows)
+		raster.Line(cols, rows, a, b, plot)
+	}
+}
+
+// plotPoints draws every model position inside the clip range into target.
+func (r *Render) plotPoints(target surface, model *mesh.Mesh) {
+	rotation := r.rotate.Matrix()
+
+	type point struct {
+		projected linalg.Vec3
+		depth     float64
+		tint      linalg.Vec3
+	}
+	points := make([]point, 0, len(model.Positions))
+	nearest, farthest := math.Inf(1), math.Inf(-1)
+	for i, position := range model.Positions {
+		vertex := rotation.MulVec(position)
+		if !r.camera.InClipRange(vertex) {
+			continue
+		}
+		depth := r.camera.Depth(vertex)
+		nearest, farthest = math.Min(nearest, depth), math.Max(farthest, depth)
+		tint := white
+		if model.HasColors() {
+			tint = model.Colors[i]
+		}
+		points = append(points, point{projected: r.getProjectedVertex(vertex), depth: depth, tint: tint})
+	}
+
+	material := model.MaterialAt(-1)
+	ramp := r.rampFor(material)
+	cols, rows := target.Size()
+	for _, p := range points {
+		// Fade from full brightness at the nearest point to 30% at the farthest.
+		intensity := 1.0
+		if farthest > nearest {
+			intensity -= 0.7 * (p.depth - nearest) / (farthest - nearest)
+		}
+		cell := shadeCell(ramp, material.Diffuse.Scale(intensity))
+		if p.tint != white {
+			cell.Fg = matrix.ColorFromVec3(material.Diffuse.Mul(p.tint).Scale(intensity))
+		}
+
+		raster.Point(cols, rows, toRaster(p.projected, cols, rows), func(f raster.Fragment) {
+			if target.DepthTest(f.X, f.Y, f.Depth) {
+				target.Plot(f.X, f.Y, cell)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package render
+
+/**
+ * Drawing targets for the rasterizer.
+ *
+ * In canvas.Cells mode triangles are rasterized straight into the matrix
+ * cells; in the other pixel modes they are rasterized into a finer canvas
+ * that is resolved into block characters once the frame is complete.
+ */
+
+import (
+	"zontengine/internal/canvas"
+	"zontengine/internal/matrix"
+	"zontengine/internal/mesh"
+)
+
+// surface is a grid that fillTriangle can draw lit fragments into.
+type surface interface {
+	Size() (width, height int)
+	// DepthTest reports whether a fragment at depth z is visible at (x, y)
+	// and, if so, records it.
+	DepthTest(x, y int, z float64) bool
+	Plot(x, y int, cell matrix.Cell)
+}
+
+// cellSurface draws into a screen buffer. Without test every fragment is
+// drawn and its depth recorded as is; a nil depth is not recorded at all.
+type cellSurface struct {
+	buffer [][]matrix.Cell
+	depth  [][]float64
+	cols   int
+	test   bool
+}
+
+func (s cellSurface) Size() (int, int) {
+	return s.cols, len(s.buffer)
+}
+
+func (s cellSurface) DepthTest(x, y int, z float64) bool {
+	switch {
+	case s.depth == nil:
+		return true
+	case s.test:
+		return matrix.DepthTest(s.depth, x, y, z)
+	}
+	s.depth[y][x] = z
+	return true
+}
+
+func (s cellSurface) Plot(x, y int, cell matrix.Cell) {
+	s.buffer[y][x] = cell
+}
+
+// pixelSurface draws the colors of lit fragments into a canvas, testing
+// their depth like cellSurface.
+type pixelSurface struct {
+	canvas *canvas.Canvas
+	test   bool
+}
+
+func (s pixelSurface) Size() (int, int) {
+	return s.canvas.Size()
+}
+
+func (s pixelSurface) DepthTest(x, y int, z float64) bool {
+	if s.test {
+		return s.canvas.DepthTest(x, y, z)
+	}
+	s.canvas.SetDepth(x, y, z)
+	return true
+}
+
+func (s pixelSurface) Plot(x, y int, cell matrix.Cell) {
+	s.canvas.Set(x, y, cell.Fg)
+}
+
+// SetPixelMode selects between one shaded character per cell (the default)
+// and the half-block, quadrant or braille modes, which draw 2, 4 or 8
+// pixels per cell and need a color profile to show shading.
+func (r *Render) SetPixelMode(mode canvas.Mode) {
+	r.pixelMode = mode
+	r.canvas = nil
+}
+
+func (r *Render) GetPixelMode() canvas.Mode {
+	return r.pixelMode
+}
+
+// rasterSize returns the size of the grid triangles are rasterized on.
+func (r *Render) rasterSize() (int, int) {
+	w, h := r.pixelMode.CellSize()
+	return r.matrix.GetCols() * w, r.matrix.GetRows() * h
+}
+
+// drawTriangles draws the model into buffer in the current draw style and
+// pixel mode. A model without faces is drawn as points. depth receives the
+// depth of the surface drawn in each cell. With DepthBuffer it also hides
+// farther fragments, so it must start out cleared.
+func (r *Render) drawTriangles(buffer [][]matrix.Cell, depth [][]float64, triangles []matrix.Triangle, model *mesh.Mesh) {
+	test := r.depthMode == DepthBuffer
+
+	var target surface = cellSurface{buffer: buffer, depth: depth, cols: r.matrix.GetCols(), test: test}
+	if r.pixelMode != canvas.Cells {
+		width, height := r.rasterSize()
+		if r.canvas == nil || !sameSize(r.canvas, width, height) {
+			r.canvas = canvas.NewCanvas(width, height)
+		}
+		r.canvas.Clear()
+		target = pixelSurface{canvas: r.canvas, test: test}
+	}
+
+	style := r.drawStyle
+	if len(model.Faces) == 0 {
+		style = Points
+	}
+	switch style {
+	case Points:
+		r.plotPoints(target, model)
+	case Wireframe:
+		for i := range triangles {
+			triangle := &triangles[i]
+			r.strokeTriangle(target, triangle, model.MaterialAt(triangle.Material))
+		}
+	default:
+		for i := range triangles {
+			triangle := &triangles[i]
+			r.fillTriangle(target, triangle, model.MaterialAt(triangle.Material))
+		}
+	}
+
+	if r.pixelMode != canvas.Cells {
+		r.canvas.Resolve(r.pixelMode, buffer)
+		r.canvas.ResolveDepth(r.pixelMode, depth)
+	}
+}
+
+func sameSize(c *canvas.Canvas, width, height int) bool {
+	w, h := c.Size()
+	return w == width && h == height
+}
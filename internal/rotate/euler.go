@@ -1,93 +1,113 @@
 package rotate
 
-/*
-!WARNING: not implemented
-*/
+/**
+ * Euler angle and axis-angle rotation matrices.
+ *
+ * An Order names the axes in the sequence they are applied to a vertex:
+ * XYZ rotates around X first, then Y, then Z, which is the matrix
+ * Rz * Ry * Rx. All angles are in radians.
+ */
 
 import (
 	"math"
+
+	"zontengine/internal/linalg"
 )
 
-type EulerRotate struct {
-	x [][]float64
-	y [][]float64
-	z [][]float64
-}
+type Order int
 
-func NewEulerRotate() *EulerRotate {
-	r := &EulerRotate{}
-	return r
-}
+const (
+	XYZ Order = iota
+	XZY
+	YXZ
+	YZX
+	ZXY
+	ZYX
+)
 
-func (r *EulerRotate) Update(x, y, z [][]float64) {
-	r.x = x
-	r.y = y
-	r.z = z
+func (o Order) String() string {
+	return string(o.axes())
 }
 
-// Z => Y => X
-func (r *EulerRotate) SetEulerAngles(yaw, pitch, roll float64) {
-	yawRad := yaw * math.Pi / 180.0
-	pitchRad := pitch * math.Pi / 180.0
-	rollRad := roll * math.Pi / 180.0
+func (o Order) axes() []byte {
+	switch o {
+	case XZY:
+		return []byte("XZY")
+	case YXZ:
+		return []byte("YXZ")
+	case YZX:
+		return []byte("YZX")
+	case ZXY:
+		return []byte("ZXY")
+	case ZYX:
+		return []byte("ZYX")
+	default:
+		return []byte("XYZ")
+	}
+}
 
-	cosZ := math.Cos(yawRad)
-	sinZ := math.Sin(yawRad)
-	zMatrix := [][]float64{
-		{cosZ, -sinZ, 0},
-		{sinZ, cosZ, 0},
-		{0, 0, 1},
+func RotationX(angle float64) linalg.Mat3 {
+	c, s := math.Cos(angle), math.Sin(angle)
+	return linalg.Mat3{
+		{1, 0, 0},
+		{0, c, -s},
+		{0, s, c},
 	}
+}
 
-	cosY := math.Cos(pitchRad)
-	sinY := math.Sin(pitchRad)
-	yMatrix := [][]float64{
-		{cosY, 0, sinY},
+func RotationY(angle float64) linalg.Mat3 {
+	c, s := math.Cos(angle), math.Sin(angle)
+	return linalg.Mat3{
+		{c, 0, s},
 		{0, 1, 0},
-		{-sinY, 0, cosY},
+		{-s, 0, c},
 	}
+}
 
-	cosX := math.Cos(rollRad)
-	sinX := math.Sin(rollRad)
-	xMatrix := [][]float64{
-		{1, 0, 0},
-		{0, cosX, -sinX},
-		{0, sinX, cosX},
+func RotationZ(angle float64) linalg.Mat3 {
+	c, s := math.Cos(angle), math.Sin(angle)
+	return linalg.Mat3{
+		{c, -s, 0},
+		{s, c, 0},
+		{0, 0, 1},
 	}
+}
 
-	r.Update(xMatrix, yMatrix, zMatrix)
+// EulerMatrix returns the 3x3 matrix rotating by x, y and z radians around
+// the corresponding axes in the given order.
+func EulerMatrix(x, y, z float64, order Order) linalg.Mat3 {
+	result := linalg.Identity3()
+	for _, axis := range order.axes() {
+		switch axis {
+		case 'X':
+			result = RotationX(x).Mul(result)
+		case 'Y':
+			result = RotationY(y).Mul(result)
+		case 'Z':
+			result = RotationZ(z).Mul(result)
+		}
+	}
+	return result
 }
 
-func (r *EulerRotate) SetAxisAngles(aX, aY, aZ, angleDeg float64) {
-	angle := angleDeg * math.Pi / 180.0
+// AxisAngleMatrix returns the Rodrigues rotation matrix for angle radians
+// around the axis (x, y, z). A zero axis yields the identity.
+func AxisAngleMatrix(x, y, z, angle float64) linalg.Mat3 {
+	length := math.Sqrt(x*x + y*y + z*z)
+	if length == 0 {
+		return linalg.Identity3()
+	}
+	x /= length
+	y /= length
+	z /= length
+
 	c := math.Cos(angle)
 	s := math.Sin(angle)
 	t := 1 - c
 
-	len := math.Sqrt(aX*aX + aY*aY + aZ*aZ)
-
-	if len > 0 {
-		aX /= len
-		aY /= len
-		aZ /= len
-	}
-
-	matrix := [][]float64{
-		{
-			t*aX*aX + c,
-			t*aX*aY + s*aZ,
-			t*aX*aZ + s*aY,
-		},
-		{
-			t*aX*aY + s*aZ,
-			t*aY*aY + c,
-			t*aY*aZ + s*aX,
-		},
-		{
-			t*aX*aZ + s*aY,
-			t*aY*aZ + s*aX,
-			t*aZ*aZ + c,
-		},
+	return linalg.Mat3{
+		{t*x*x + c, t*x*y - s*z, t*x*z + s*y},
+		{t*x*y + s*z, t*y*y + c, t*y*z - s*x},
+		{t*x*z - s*y, t*y*z + s*x, t*z*z + c},
 	}
-	r.Update(matrix, matrix, matrix)
 }
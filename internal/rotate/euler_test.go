@@ -0,0 +1,73 @@
+package rotate
+
+import (
+	"math"
+	"testing"
+
+	"zontengine/internal/linalg"
+)
+
+const epsilon = 1e-9
+
+func nearMat3(a, b linalg.Mat3) bool {
+	for row := range a {
+		for col := range a[row] {
+			if math.Abs(a[row][col]-b[row][col]) > epsilon {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func nearVec3(a, b linalg.Vec3) bool {
+	return a.Sub(b).Len() <= epsilon
+}
+
+func TestEulerMatrixMatchesQuaternion(t *testing.T) {
+	angles := [][3]float64{
+		{0, 0, 0},
+		{math.Pi / 2, 0, 0},
+		{0.3, -1.2, 2.5},
+		{-math.Pi, math.Pi / 3, 0.1},
+		{4, 5, -6},
+	}
+
+	for _, order := range []Order{XYZ, XZY, YXZ, YZX, ZXY, ZYX} {
+		t.Run(order.String(), func(t *testing.T) {
+			for _, a := range angles {
+				matrix := EulerMatrix(a[0], a[1], a[2], order)
+				if q := FromEuler(a[0], a[1], a[2], order).Matrix(); !nearMat3(matrix, q) {
+					t.Errorf("angles %v: EulerMatrix is %v, FromEuler gives %v", a, matrix, q)
+				}
+			}
+		})
+	}
+}
+
+func TestEulerOrder(t *testing.T) {
+	x, y, z := 0.4, -0.7, 1.1
+	want := RotationZ(z).Mul(RotationY(y)).Mul(RotationX(x))
+	if got := EulerMatrix(x, y, z, XYZ); !nearMat3(got, want) {
+		t.Errorf("EulerMatrix(XYZ) is %v, want Rz * Ry * Rx = %v", got, want)
+	}
+
+	// A quarter turn around X then Y takes up to +X; around Y first, up
+	// stays put and then turns to +Z.
+	up := linalg.Vec3{Y: 1}
+	tests := []struct {
+		order Order
+		want  linalg.Vec3
+	}{
+		{XYZ, linalg.Vec3{X: 1}},
+		{YXZ, linalg.Vec3{Z: 1}},
+	}
+	for _, tt := range tests {
+		if got := EulerMatrix(math.Pi/2, math.Pi/2, 0, tt.order).MulVec(up); !nearVec3(got, tt.want) {
+			t.Errorf("%v turns up to %v, want %v", tt.order, got, tt.want)
+		}
+		if got := FromEuler(math.Pi/2, math.Pi/2, 0, tt.order).Rotate(up); !nearVec3(got, tt.want) {
+			t.Errorf("FromEuler %v turns up to %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package rotate
+
+/**
+ * Unit quaternions for composing and interpolating 3D orientations without
+ * gimbal lock. A quaternion q rotates a vector v as q * v * conj(q), and the
+ * product a.Mul(b) applies b first and then a, matching matrix composition.
+ */
+
+import (
+	"math"
+
+	"zontengine/internal/linalg"
+)
+
+type Quaternion struct {
+	W, X, Y, Z float64
+}
+
+func IdentityQuaternion() Quaternion {
+	return Quaternion{W: 1}
+}
+
+// FromAxisAngle returns the rotation by angle radians around the axis
+// (x, y, z). The axis does not need to be normalized; a zero axis yields
+// the identity.
+func FromAxisAngle(x, y, z, angle float64) Quaternion {
+	length := math.Sqrt(x*x + y*y + z*z)
+	if length == 0 {
+		return IdentityQuaternion()
+	}
+	s := math.Sin(angle/2) / length
+	return Quaternion{W: math.Cos(angle / 2), X: x * s, Y: y * s, Z: z * s}
+}
+
+// FromEuler returns the rotation by x, y and z radians around the
+// corresponding axes, applied in the given order.
+func FromEuler(x, y, z float64, order Order) Quaternion {
+	qx := FromAxisAngle(1, 0, 0, x)
+	qy := FromAxisAngle(0, 1, 0, y)
+	qz := FromAxisAngle(0, 0, 1, z)
+
+	q := IdentityQuaternion()
+	for _, axis := range order.axes() {
+		switch axis {
+		case 'X':
+			q = qx.Mul(q)
+		case 'Y':
+			q = qy.Mul(q)
+		case 'Z':
+			q = qz.Mul(q)
+		}
+	}
+	return q
+}
+
+// Mul returns q * p, the rotation that applies p first and then q.
+func (q Quaternion) Mul(p Quaternion) Quaternion {
+	return Quaternion{
+		W: q.W*p.W - q.X*p.X - q.Y*p.Y - q.Z*p.Z,
+		X: q.W*p.X + q.X*p.W + q.Y*p.Z - q.Z*p.Y,
+		Y: q.W*p.Y - q.X*p.Z + q.Y*p.W + q.Z*p.X,
+		Z: q.W*p.Z + q.X*p.Y - q.Y*p.X + q.Z*p.W,
+	}
+}
+
+func (q Quaternion) Dot(p Quaternion) float64 {
+	return q.W*p.W + q.X*p.X + q.Y*p.Y + q.Z*p.Z
+}
+
+func (q Quaternion) Len() float64 {
+	return math.Sqrt(q.Dot(q))
+}
+
+// Normalize returns q scaled to unit length, or the identity for a zero q.
+func (q Quaternion) Normalize() Quaternion {
+	length := q.Len()
+	if length == 0 {
+		return IdentityQuaternion()
+	}
+	return Quaternion{W: q.W / length, X: q.X / length, Y: q.Y / length, Z: q.Z / length}
+}
+
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+}
+
+func (q Quaternion) Inverse() Quaternion {
+	n := q.Dot(q)
+	if n == 0 {
+		return IdentityQuaternion()
+	}
+	c := q.Conjugate()
+	return Quaternion{W: c.W / n, X: c.X / n, Y: c.Y / n, Z: c.Z / n}
+}
+
+// AxisAngle returns the normalized rotation axis and the angle in radians.
+// The identity rotation reports the X axis and a zero angle.
+func (q Quaternion) AxisAngle() (x, y, z, angle float64) {
+	q = q.Normalize()
+	if q.W < 0 {
+		q = Quaternion{W: -q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+	}
+	s := math.Sqrt(1 - q.W*q.W)
+	if s < 1e-9 {
+		return 1, 0, 0, 0
+	}
+	return q.X / s, q.Y / s, q.Z / s, 2 * math.Acos(q.W)
+}
+
+// Rotate applies the rotation to a 3D vector.
+func (q Quaternion) Rotate(v linalg.Vec3) linalg.Vec3 {
+	p := q.Mul(Quaternion{X: v.X, Y: v.Y, Z: v.Z}).Mul(q.Conjugate())
+	return linalg.Vec3{X: p.X, Y: p.Y, Z: p.Z}
+}
+
+// Matrix returns the equivalent 3x3 rotation matrix of the normalized q.
+func (q Quaternion) Matrix() linalg.Mat3 {
+	q = q.Normalize()
+	w, x, y, z := q.W, q.X, q.Y, q.Z
+
+	return linalg.Mat3{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}
+}
+
+// Slerp interpolates along the shortest arc between a and b; t=0 yields a
+// and t=1 yields b.
+func Slerp(a, b Quaternion, t float64) Quaternion {
+	a = a.Normalize()
+	b = b.Normalize()
+
+	cos := a.Dot(b)
+	if cos < 0 {
+		b = Quaternion{W: -b.W, X: -b.X, Y: -b.Y, Z: -b.Z}
+		cos = -cos
+	}
+
+	// Nearly parallel: fall back to a normalized lerp to avoid dividing by ~0.
+	if cos > 0.9995 {
+		return Quaternion{
+			W: a.W + (b.W-a.W)*t,
+			X: a.X + (b.X-a.X)*t,
+			Y: a.Y + (b.Y-a.Y)*t,
+			Z: a.Z + (b.Z-a.Z)*t,
+		}.Normalize()
+	}
+
+	theta := math.Acos(cos)
+	sin := math.Sin(theta)
+	wa := math.Sin((1-t)*theta) / sin
+	wb := math.Sin(t*theta) / sin
+
+	return Quaternion{
+		W: a.W*wa + b.W*wb,
+		X: a.X*wa + b.X*wb,
+		Y: a.Y*wa + b.Y*wb,
+		Z: a.Z*wa + b.Z*wb,
+	}
+}
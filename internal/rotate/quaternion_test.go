@@ -0,0 +1,70 @@
+package rotate
+
+import (
+	"math"
+	"testing"
+)
+
+// sameRotation reports whether a and b are the same rotation; q and -q are.
+func sameRotation(a, b Quaternion) bool {
+	return math.Abs(math.Abs(a.Normalize().Dot(b.Normalize()))-1) <= epsilon
+}
+
+func nearQuaternion(a, b Quaternion) bool {
+	return math.Abs(a.W-b.W) <= epsilon && math.Abs(a.X-b.X) <= epsilon &&
+		math.Abs(a.Y-b.Y) <= epsilon && math.Abs(a.Z-b.Z) <= epsilon
+}
+
+func TestSlerp(t *testing.T) {
+	quarter := FromAxisAngle(0, 0, 1, math.Pi/2)
+	tests := []struct {
+		name string
+		a, b Quaternion
+		t    float64
+		want Quaternion
+	}{
+		{"start", IdentityQuaternion(), quarter, 0, IdentityQuaternion()},
+		{"end", IdentityQuaternion(), quarter, 1, quarter},
+		{"midpoint", IdentityQuaternion(), quarter, 0.5, FromAxisAngle(0, 0, 1, math.Pi/4)},
+		{"quarter way", FromAxisAngle(1, 0, 0, -1), FromAxisAngle(1, 0, 0, 1), 0.25, FromAxisAngle(1, 0, 0, -0.5)},
+		{"unnormalized", Quaternion{W: 2}, Quaternion{W: 3, Z: 3}, 0.5, FromAxisAngle(0, 0, 1, math.Pi/4)},
+		{"nearly parallel", IdentityQuaternion(), FromAxisAngle(0, 1, 0, 0.01), 0.5, FromAxisAngle(0, 1, 0, 0.005)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Slerp(tt.a, tt.b, tt.t)
+			if !nearQuaternion(got, tt.want) {
+				t.Errorf("Slerp = %+v, want %+v", got, tt.want)
+			}
+			if length := got.Len(); math.Abs(length-1) > epsilon {
+				t.Errorf("Slerp has length %v, want 1", length)
+			}
+		})
+	}
+}
+
+func TestSlerpShortestPath(t *testing.T) {
+	a := IdentityQuaternion()
+	quarter := FromAxisAngle(0, 0, 1, math.Pi/2)
+	// Negated, the quarter turn is the same rotation on the far side of the
+	// 4D sphere; interpolating toward it directly would go the long way.
+	negated := Quaternion{W: -quarter.W, X: -quarter.X, Y: -quarter.Y, Z: -quarter.Z}
+
+	for _, step := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		got := Slerp(a, negated, step)
+		want := FromAxisAngle(0, 0, 1, step*math.Pi/2)
+		if !sameRotation(got, want) {
+			_, _, _, angle := got.AxisAngle()
+			t.Errorf("t=%v turns by %v, want %v", step, angle, step*math.Pi/2)
+		}
+		if got.Dot(a) < 0 {
+			t.Errorf("t=%v left the hemisphere of the start: %+v", step, got)
+		}
+	}
+
+	// The end is the target rotation, in the sign of the start.
+	if got := Slerp(a, negated, 1); !nearQuaternion(got, quarter) {
+		t.Errorf("Slerp(a, -b, 1) = %+v, want %+v", got, quarter)
+	}
+}
@@ -1,42 +1,67 @@
 package rotate
 
 /**
- * Manages 3D rotation matrices for transforming 3D vertices.
- * Stores and provides access to X, Y, and Z axis rotation matrices.
+ * Manages the orientation used to transform 3D vertices.
+ * Stores the orientation as a unit quaternion together with the equivalent
+ * 3x3 rotation matrix that the renderer multiplies vertices by.
  *
- * The rotation matrices are used to transform 3D coordinates by:
- * - X-axis rotation: pitch (rotation around horizontal axis)
- * - Y-axis rotation: yaw (rotation around vertical axis)
- * - Z-axis rotation: roll (rotation around depth axis)
+ * The orientation can be set from:
+ * - Euler angles with a selectable axis order
+ * - An axis and an angle
+ * - A quaternion, directly or interpolated with Slerp
  *
- * These matrices are typically updated from the renderer based on
- * the current rotation angle and applied to vertices during processing.
+ * It is typically updated from the renderer based on the model pose and
+ * the current animation angle, and applied to vertices during processing.
  */
 
+import (
+	"math"
+
+	"zontengine/internal/linalg"
+)
+
 type Rotate struct {
-	x [][]float64
-	y [][]float64
-	z [][]float64
+	orientation Quaternion
+	matrix      linalg.Mat3
 }
 
 func NewRotate() *Rotate {
-	return &Rotate{}
+	r := &Rotate{}
+	r.Set(IdentityQuaternion())
+	return r
+}
+
+// Set replaces the orientation; q is normalized before use.
+func (r *Rotate) Set(q Quaternion) {
+	r.orientation = q.Normalize()
+	r.matrix = r.orientation.Matrix()
+}
+
+// SetEuler sets the orientation from x, y and z radians applied in order.
+func (r *Rotate) SetEuler(x, y, z float64, order Order) {
+	r.Set(FromEuler(x, y, z, order))
+}
+
+// SetAxisAngle sets the orientation to angle radians around (x, y, z).
+func (r *Rotate) SetAxisAngle(x, y, z, angle float64) {
+	r.Set(FromAxisAngle(x, y, z, angle))
 }
 
-func (r *Rotate) Update(x, y, z [][]float64) {
-	r.x = x
-	r.y = y
-	r.z = z
+// SetEulerAngles sets the orientation from angles in degrees, yawing
+// around Z first, then pitching around Y and finally rolling around X.
+func (r *Rotate) SetEulerAngles(yaw, pitch, roll float64) {
+	r.SetEuler(roll*math.Pi/180.0, pitch*math.Pi/180.0, yaw*math.Pi/180.0, ZYX)
 }
 
-func (r *Rotate) GetX() [][]float64 {
-	return r.x
+// SetAxisAngles sets the orientation to angleDeg degrees around (aX, aY, aZ).
+func (r *Rotate) SetAxisAngles(aX, aY, aZ, angleDeg float64) {
+	r.SetAxisAngle(aX, aY, aZ, angleDeg*math.Pi/180.0)
 }
 
-func (r *Rotate) GetY() [][]float64 {
-	return r.y
+func (r *Rotate) Orientation() Quaternion {
+	return r.orientation
 }
 
-func (r *Rotate) GetZ() [][]float64 {
-	return r.z
+func (r *Rotate) Matrix() linalg.Mat3 {
+	return r.matrix
 }
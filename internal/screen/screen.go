@@ -4,40 +4,296 @@ package screen
  * Handles terminal screen operations including initialization, clearing,
  * and rendering of the screen buffer. Manages the display of ASCII graphics
  * in the terminal with proper formatting and cursor control.
+ *
+ * Cell colors are written as ANSI escape sequences reduced to the color
+ * profile of the output: none (plain characters), 16 colors, 256 colors or
+ * 24-bit truecolor. The profile is detected from the environment (TERM,
+ * COLORTERM, NO_COLOR, CLICOLOR_FORCE) and whether the output is a terminal.
+ *
+ * After the first frame only the cells that changed since the previously
+ * emitted frame are sent, as cursor moves followed by runs of cells; short
+ * gaps of unchanged cells are resent when that is cheaper than another
+ * cursor move. SetFullRedraw turns this off, and DrawStats reports how many
+ * bytes and cells each frame took.
  */
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"sync"
 	"zontengine/internal/matrix"
+
+	"github.com/muesli/termenv"
 )
 
 type Screen struct {
-	matrix *matrix.Matrix
+	matrix  *matrix.Matrix
+	out     io.Writer
+	profile termenv.Profile
+
+	// sgr caches the escape sequence for each foreground/background pair.
+	sgr map[[2]matrix.Color]string
+
+	fullRedraw bool
+	// previous is a copy of the last frame emitted, nil before the first.
+	previous [][]matrix.Cell
+
+	statsMutex sync.Mutex
+	stats      DrawStats
+}
+
+// DrawStats counts the output of DrawScreen.
+type DrawStats struct {
+	// Frames is the number of frames drawn, FullFrames how many of them
+	// were redrawn completely rather than as changes.
+	Frames     int
+	FullFrames int
+	// LastBytes is the size of the most recent frame's output.
+	LastBytes  int
+	TotalBytes int64
+	// LastCells is the number of cells the most recent frame wrote,
+	// including unchanged cells resent to fill a gap.
+	LastCells  int
+	TotalCells int64
+}
+
+// AverageBytes returns the mean output size per frame.
+func (s DrawStats) AverageBytes() float64 {
+	if s.Frames == 0 {
+		return 0
+	}
+	return float64(s.TotalBytes) / float64(s.Frames)
 }
 
+// maxGap is the longest run of unchanged cells resent to join two changed
+// runs; a cursor move costs about as much.
+const maxGap = 6
+
 func NewScreen(matrix *matrix.Matrix) *Screen {
-	return &Screen{matrix: matrix}
+	s := &Screen{matrix: matrix}
+	s.SetOutput(os.Stdout)
+	return s
+}
+
+// SetOutput redirects drawing from stdout to w and detects the color
+// profile w supports; call SetColorProfile afterwards to override it.
+func (s *Screen) SetOutput(w io.Writer) {
+	s.out = w
+	s.SetColorProfile(termenv.NewOutput(w).EnvColorProfile())
+}
+
+func (s *Screen) GetOutput() io.Writer {
+	return s.out
+}
+
+// SetFullRedraw makes every frame rewrite the whole screen instead of only
+// the cells that changed, e.g. after something else wrote to the terminal.
+func (s *Screen) SetFullRedraw(full bool) {
+	s.fullRedraw = full
+}
+
+func (s *Screen) DrawStats() DrawStats {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	return s.stats
+}
+
+// SetColorProfile selects how cell colors are written: termenv.Ascii
+// disables them, ANSI, ANSI256 and TrueColor use 16, 256 or 24-bit colors.
+func (s *Screen) SetColorProfile(profile termenv.Profile) {
+	s.profile = profile
+	s.sgr = make(map[[2]matrix.Color]string)
+}
+
+func (s *Screen) GetColorProfile() termenv.Profile {
+	return s.profile
 }
 
-func (s *Screen) InitScreen(screen [][]rune) {
+// ParseColorProfile converts "auto", "none", "16", "256" or "truecolor" to
+// a profile; "auto" detects it from the environment and stdout.
+func ParseColorProfile(name string) (termenv.Profile, error) {
+	switch strings.ToLower(name) {
+	case "auto", "":
+		return termenv.EnvColorProfile(), nil
+	case "none", "ascii":
+		return termenv.Ascii, nil
+	case "16", "ansi":
+		return termenv.ANSI, nil
+	case "256", "ansi256":
+		return termenv.ANSI256, nil
+	case "truecolor", "24bit":
+		return termenv.TrueColor, nil
+	}
+	return termenv.Ascii, fmt.Errorf("unknown color mode %q (want auto, none, 16, 256 or truecolor)", name)
+}
+
+func (s *Screen) InitScreen(screen [][]matrix.Cell) {
 	for row := 0; row < len(screen); row++ {
 		for col := 0; col < len(screen[0])-1; col++ {
-			screen[row][col] = ' '
+			screen[row][col] = matrix.BlankCell
 		}
-		screen[row][s.matrix.GetCols()] = '\n'
+		screen[row][s.matrix.GetCols()] = matrix.NewlineCell
 	}
 }
 
-func (s *Screen) DrawScreen() {
+// DrawScreen prints the newest frame presented by the renderer, or only
+// its differences from the previous one.
+func (s *Screen) DrawScreen() error {
+	front, _ := s.matrix.AcquireFront()
+
 	var buffer strings.Builder
+	full := s.fullRedraw || !sameShape(s.previous, front)
+	var cells int
+	if full {
+		cells = s.writeFull(&buffer, front)
+	} else {
+		cells = s.writeChanges(&buffer, front)
+	}
+	s.remember(front)
+
+	n, err := io.WriteString(s.out, buffer.String())
+
+	s.statsMutex.Lock()
+	s.stats.Frames++
+	if full {
+		s.stats.FullFrames++
+	}
+	s.stats.LastBytes = n
+	s.stats.TotalBytes += int64(n)
+	s.stats.LastCells = cells
+	s.stats.TotalCells += int64(cells)
+	s.statsMutex.Unlock()
 
-	for row := 0; row < len(s.matrix.ScreenBuffer[1]); row++ {
-		for col := 0; col < len(s.matrix.ScreenBuffer[1][0]); col++ {
-			buffer.WriteRune(s.matrix.ScreenBuffer[1][row][col])
+	return err
+}
+
+// writeFull rewrites every cell of frame and returns how many it wrote, not
+// counting the newlines.
+func (s *Screen) writeFull(buffer *strings.Builder, frame [][]matrix.Cell) int {
+	buffer.WriteString("\033[H")
+
+	// A frame of another size would leave parts of the previous one behind.
+	if !sameShape(s.previous, frame) {
+		buffer.WriteString("\033[2J")
+	}
+
+	cells := 0
+	for row := 0; row < len(frame); row++ {
+		current := [2]matrix.Color{}
+		for _, cell := range frame[row] {
+			s.writeCell(buffer, cell, &current)
+			if cell.Ch != '\n' {
+				cells++
+			}
+		}
+		s.resetColors(buffer, &current)
+	}
+	return cells
+}
+
+// writeChanges moves the cursor to every run of cells that differs from
+// the previous frame and rewrites the run, returning how many cells it
+// wrote.
+func (s *Screen) writeChanges(buffer *strings.Builder, frame [][]matrix.Cell) int {
+	written := 0
+	current := [2]matrix.Color{}
+	for row := range frame {
+		cells, previous := frame[row], s.previous[row]
+		for col := 0; col < len(cells); col++ {
+			if cells[col] == previous[col] || cells[col].Ch == '\n' {
+				continue
+			}
+
+			end := col + 1
+			for next := end; next < len(cells) && next-end <= maxGap; next++ {
+				if cells[next] != previous[next] && cells[next].Ch != '\n' {
+					end = next + 1
+				}
+			}
+
+			fmt.Fprintf(buffer, "\033[%d;%dH", row+1, col+1)
+			for _, cell := range cells[col:end] {
+				s.writeCell(buffer, cell, &current)
+			}
+			written += end - col
+			col = end
+		}
+	}
+	s.resetColors(buffer, &current)
+	return written
+}
+
+// writeCell writes one cell, switching colors first if they differ from
+// current. A newline resets the colors so that they never bleed into the
+// rest of the line.
+func (s *Screen) writeCell(buffer *strings.Builder, cell matrix.Cell, current *[2]matrix.Color) {
+	if s.profile != termenv.Ascii {
+		colors := [2]matrix.Color{cell.Fg, cell.Bg}
+		if cell.Ch == '\n' {
+			colors = [2]matrix.Color{}
+		}
+		if colors != *current {
+			buffer.WriteString(s.sequence(colors))
+			*current = colors
+		}
+	}
+	buffer.WriteRune(cell.Ch)
+}
+
+func (s *Screen) resetColors(buffer *strings.Builder, current *[2]matrix.Color) {
+	if *current != ([2]matrix.Color{}) {
+		buffer.WriteString(termenv.CSI + termenv.ResetSeq + "m")
+		*current = [2]matrix.Color{}
+	}
+}
+
+// remember copies frame into s.previous, reusing its rows when they fit.
+func (s *Screen) remember(frame [][]matrix.Cell) {
+	if !sameShape(s.previous, frame) {
+		s.previous = make([][]matrix.Cell, len(frame))
+		for row := range frame {
+			s.previous[row] = make([]matrix.Cell, len(frame[row]))
+		}
+	}
+	for row := range frame {
+		copy(s.previous[row], frame[row])
+	}
+}
+
+func sameShape(a, b [][]matrix.Cell) bool {
+	if a == nil || len(a) != len(b) {
+		return false
+	}
+	for row := range a {
+		if len(a[row]) != len(b[row]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sequence returns the SGR escape sequence selecting the given foreground
+// and background in the current profile.
+func (s *Screen) sequence(colors [2]matrix.Color) string {
+	if seq, exists := s.sgr[colors]; exists {
+		return seq
+	}
+
+	params := []string{termenv.ResetSeq}
+	if !colors[0].IsDefault() {
+		if fg := s.profile.Color(colors[0].Hex()).Sequence(false); fg != "" {
+			params = append(params, fg)
+		}
+	}
+	if !colors[1].IsDefault() {
+		if bg := s.profile.Color(colors[1].Hex()).Sequence(true); bg != "" {
+			params = append(params, bg)
 		}
 	}
+	seq := termenv.CSI + strings.Join(params, ";") + "m"
 
-	fmt.Print("\033[H")
-	fmt.Print(buffer.String())
+	s.sgr[colors] = seq
+	return seq
 }
@@ -0,0 +1,384 @@
+package screen
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"zontengine/internal/matrix"
+
+	"github.com/muesli/termenv"
+)
+
+// terminal is a tiny VT emulator understanding what the screen writes:
+// cursor positioning, clearing, truecolor SGR, newlines and printed cells.
+type terminal struct {
+	t     *testing.T
+	cells [][]matrix.Cell
+	row   int
+	col   int
+	pen   [2]matrix.Color
+	// printed counts the cells printed since the last replay.
+	printed int
+}
+
+// newTerminal returns a terminal filled with garbage, so that a cell the
+// screen never wrote shows up in a comparison.
+func newTerminal(t *testing.T, cols, rows int) *terminal {
+	term := &terminal{t: t, cells: make([][]matrix.Cell, rows)}
+	for row := range term.cells {
+		term.cells[row] = make([]matrix.Cell, cols)
+		for col := range term.cells[row] {
+			term.cells[row][col] = matrix.Cell{Ch: '#'}
+		}
+	}
+	return term
+}
+
+func (term *terminal) replay(output string) {
+	term.t.Helper()
+	term.printed = 0
+	runes := []rune(output)
+	for i := 0; i < len(runes); i++ {
+		switch ch := runes[i]; ch {
+		case '\033':
+			if i+1 >= len(runes) || runes[i+1] != '[' {
+				term.t.Fatalf("escape without CSI at %d in %q", i, output)
+			}
+			end := i + 2
+			for end < len(runes) && (runes[end] < 0x40 || runes[end] > 0x7e) {
+				end++
+			}
+			if end == len(runes) {
+				term.t.Fatalf("unterminated escape sequence in %q", output)
+			}
+			term.control(string(runes[i+2:end]), runes[end])
+			i = end
+		case '\n':
+			term.row, term.col = term.row+1, 0
+		default:
+			if term.row >= len(term.cells) || term.col >= len(term.cells[term.row]) {
+				term.t.Fatalf("%q printed at (%d, %d) outside the screen", ch, term.row, term.col)
+			}
+			term.cells[term.row][term.col] = matrix.Cell{Ch: ch, Fg: term.pen[0], Bg: term.pen[1]}
+			term.col++
+			term.printed++
+		}
+	}
+}
+
+func (term *terminal) control(params string, final rune) {
+	term.t.Helper()
+	switch {
+	case final == 'H' && params == "":
+		term.row, term.col = 0, 0
+	case final == 'H':
+		var row, col int
+		if fields := strings.Split(params, ";"); len(fields) == 2 {
+			row, _ = strconv.Atoi(fields[0])
+			col, _ = strconv.Atoi(fields[1])
+		}
+		if row < 1 || col < 1 {
+			term.t.Fatalf("bad cursor position %q", params)
+		}
+		term.row, term.col = row-1, col-1
+	case final == 'J' && params == "2":
+		for _, cells := range term.cells {
+			for col := range cells {
+				cells[col] = matrix.BlankCell
+			}
+		}
+	case final == 'm':
+		term.sgr(params)
+	default:
+		term.t.Fatalf("unexpected escape sequence %q%c", params, final)
+	}
+}
+
+func (term *terminal) sgr(params string) {
+	term.t.Helper()
+	fields := strings.Split(params, ";")
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "0", "":
+			term.pen = [2]matrix.Color{}
+		case "38", "48":
+			if i+4 >= len(fields) || fields[i+1] != "2" {
+				term.t.Fatalf("bad color in SGR %q", params)
+			}
+			var rgb [3]uint8
+			for c := range rgb {
+				value, err := strconv.Atoi(fields[i+2+c])
+				if err != nil || value < 0 || value > 255 {
+					term.t.Fatalf("bad color in SGR %q", params)
+				}
+				rgb[c] = uint8(value)
+			}
+			term.pen[map[string]int{"38": 0, "48": 1}[fields[i]]] = matrix.RGB(rgb[0], rgb[1], rgb[2])
+			i += 4
+		default:
+			term.t.Fatalf("unexpected SGR parameter %q in %q", fields[i], params)
+		}
+	}
+}
+
+// check fails the test unless the terminal shows frame, ignoring its
+// newline column.
+func (term *terminal) check(frame [][]matrix.Cell) {
+	term.t.Helper()
+	for row := range frame {
+		for col, want := range frame[row][:len(frame[row])-1] {
+			if got := term.cells[row][col]; got != want {
+				term.t.Fatalf("cell (%d, %d) shows %q %v/%v, want %q %v/%v",
+					row, col, got.Ch, got.Fg, got.Bg, want.Ch, want.Fg, want.Bg)
+			}
+		}
+	}
+}
+
+// textFrame returns a frame showing lines in the default colors.
+func textFrame(lines ...string) [][]matrix.Cell {
+	frame := make([][]matrix.Cell, len(lines))
+	for row, line := range lines {
+		for _, ch := range line {
+			frame[row] = append(frame[row], matrix.Cell{Ch: ch})
+		}
+		frame[row] = append(frame[row], matrix.NewlineCell)
+	}
+	return frame
+}
+
+// paint colors the cells from..to-1 of a row and returns the frame.
+func paint(frame [][]matrix.Cell, row, from, to int, fg, bg matrix.Color) [][]matrix.Cell {
+	for col := from; col < to; col++ {
+		frame[row][col].Fg, frame[row][col].Bg = fg, bg
+	}
+	return frame
+}
+
+// newTestScreen returns a truecolor screen writing to a buffer.
+func newTestScreen(cols, rows int) (*Screen, *matrix.Matrix, *bytes.Buffer) {
+	m := matrix.NewMatrix(cols, rows)
+	s := NewScreen(m)
+	var out bytes.Buffer
+	s.SetOutput(&out)
+	s.SetColorProfile(termenv.TrueColor)
+	return s, m, &out
+}
+
+func present(m *matrix.Matrix, frame [][]matrix.Cell) {
+	back := m.Back()
+	for row := range frame {
+		copy(back[row], frame[row])
+	}
+	m.Present()
+}
+
+func TestDrawScreenReplay(t *testing.T) {
+	red, blue := matrix.RGB(255, 0, 0), matrix.RGB(0, 0, 255)
+	tests := []struct {
+		name  string
+		frame [][]matrix.Cell
+		// cells is how many cells the frame should write.
+		cells int
+	}{
+		{"first frame", textFrame("hello     ", "          ", "     world"), 30},
+		{"unchanged", textFrame("hello     ", "          ", "     world"), 0},
+		{"gap resent", textFrame("jello  x  ", "          ", "     world"), 8},
+		{"gap too long", textFrame("jello  x  ", "a        b", "     world"), 2},
+		{"colors only", paint(textFrame("jello  x  ", "a        b", "     world"), 2, 5, 8, red, blue), 3},
+		{"colors split", paint(paint(textFrame("jello  x  ", "a        b", "     world"), 2, 5, 7, red, blue), 2, 8, 10, blue, 0), 3},
+		{"colors removed", textFrame("jello  x  ", "a        b", "     world"), 5},
+		{"last column", textFrame("jello  x !", "a        b", "     world"), 1},
+		{"everything", paint(textFrame("0123456789", "abcdefghij", "ABCDEFGHIJ"), 1, 0, 10, 0, red), 30},
+	}
+
+	s, m, out := newTestScreen(10, 3)
+	term := newTerminal(t, 10, 3)
+	var total int64
+	for i, tt := range tests {
+		present(m, tt.frame)
+		out.Reset()
+		if err := s.DrawScreen(); err != nil {
+			t.Fatalf("%s: DrawScreen: %v", tt.name, err)
+		}
+		term.replay(out.String())
+		term.check(tt.frame)
+
+		stats := s.DrawStats()
+		total += int64(out.Len())
+		if stats.Frames != i+1 || stats.FullFrames != 1 {
+			t.Errorf("%s: %d frames, %d full; want %d, 1", tt.name, stats.Frames, stats.FullFrames, i+1)
+		}
+		if stats.LastBytes != out.Len() || stats.TotalBytes != total {
+			t.Errorf("%s: stats count %d bytes, %d in total; %d and %d were written",
+				tt.name, stats.LastBytes, stats.TotalBytes, out.Len(), total)
+		}
+		if stats.LastCells != term.printed || stats.LastCells != tt.cells {
+			t.Errorf("%s: stats count %d cells, %d were printed; want %d", tt.name, stats.LastCells, term.printed, tt.cells)
+		}
+	}
+	if want := int64(82); s.DrawStats().TotalCells != want {
+		t.Errorf("stats count %d cells in total, want %d", s.DrawStats().TotalCells, want)
+	}
+}
+
+func TestDrawScreenResize(t *testing.T) {
+	s, m, out := newTestScreen(6, 2)
+	present(m, textFrame("abcdef", "ghijkl"))
+	if err := s.DrawScreen(); err != nil {
+		t.Fatalf("DrawScreen: %v", err)
+	}
+
+	// A frame of another size is redrawn on a cleared screen.
+	m.Resize(4, 3)
+	frame := textFrame("wxyz", "    ", "1234")
+	present(m, frame)
+	out.Reset()
+	if err := s.DrawScreen(); err != nil {
+		t.Fatalf("DrawScreen: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "\033[H\033[2J") {
+		t.Errorf("resized frame does not clear the screen: %q", out.String())
+	}
+	term := newTerminal(t, 4, 3)
+	term.replay(out.String())
+	term.check(frame)
+	if stats := s.DrawStats(); stats.FullFrames != 2 || stats.LastCells != 12 {
+		t.Errorf("resized frame: %d full frames, %d cells; want 2 and 12", stats.FullFrames, stats.LastCells)
+	}
+}
+
+func TestFullRedraw(t *testing.T) {
+	frames := [][][]matrix.Cell{
+		textFrame("one ", "    "),
+		textFrame("one ", "    "),
+		paint(textFrame("two ", "    "), 1, 1, 3, matrix.RGB(0, 255, 0), 0),
+	}
+
+	s, m, out := newTestScreen(4, 2)
+	s.SetFullRedraw(true)
+	for i, frame := range frames {
+		present(m, frame)
+		out.Reset()
+		if err := s.DrawScreen(); err != nil {
+			t.Fatalf("frame %d: DrawScreen: %v", i, err)
+		}
+		if !strings.HasPrefix(out.String(), "\033[H") {
+			t.Errorf("frame %d does not start at the top left: %q", i, out.String())
+		}
+		// Only the contents of a full frame draw over the garbage.
+		term := newTerminal(t, 4, 2)
+		term.replay(strings.TrimPrefix(out.String(), "\033[H\033[2J"))
+		term.check(frame)
+
+		stats := s.DrawStats()
+		if stats.FullFrames != i+1 || stats.LastCells != 8 || stats.LastBytes != out.Len() {
+			t.Errorf("frame %d: %d full frames, %d cells, %d bytes; want %d, 8, %d",
+				i, stats.FullFrames, stats.LastCells, stats.LastBytes, i+1, out.Len())
+		}
+	}
+}
+
+func TestParseColorProfile(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  termenv.Profile
+	}{
+		{[]string{"none", "ascii", "NONE"}, termenv.Ascii},
+		{[]string{"16", "ansi", "ANSI"}, termenv.ANSI},
+		{[]string{"256", "ansi256"}, termenv.ANSI256},
+		{[]string{"truecolor", "24bit", "TrueColor"}, termenv.TrueColor},
+	}
+	for _, tt := range tests {
+		for _, name := range tt.names {
+			if got, err := ParseColorProfile(name); err != nil || got != tt.want {
+				t.Errorf("ParseColorProfile(%q) = %v, %v; want %v", name, got, err, tt.want)
+			}
+		}
+	}
+
+	for _, name := range []string{"8", "rgb", "auto256"} {
+		if _, err := ParseColorProfile(name); err == nil {
+			t.Errorf("ParseColorProfile(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestParseColorProfileAuto(t *testing.T) {
+	// CI makes termenv treat stdout as no terminal whatever runs the test.
+	tests := []struct {
+		name string
+		env  map[string]string
+		want termenv.Profile
+	}{
+		{"no terminal", map[string]string{"CI": "1"}, termenv.Ascii},
+		{"forced", map[string]string{"CI": "1", "CLICOLOR_FORCE": "1"}, termenv.ANSI},
+		{"disabled", map[string]string{"CI": "1", "CLICOLOR_FORCE": "1", "NO_COLOR": "1"}, termenv.Ascii},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"CI", "CLICOLOR", "CLICOLOR_FORCE", "NO_COLOR"} {
+				t.Setenv(key, tt.env[key])
+			}
+			for _, name := range []string{"auto", "AUTO", ""} {
+				if got, err := ParseColorProfile(name); err != nil || got != tt.want {
+					t.Errorf("ParseColorProfile(%q) = %v, %v; want %v", name, got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+var sgrSequence = regexp.MustCompile(`\033\[[0-9;]*m`)
+
+func TestColorProfileSequences(t *testing.T) {
+	red, blue := matrix.RGB(255, 0, 0), matrix.RGB(0, 0, 255)
+	frame := paint(paint(textFrame("ab  ", "  cd"), 0, 0, 2, red, blue), 1, 2, 4, 0, matrix.RGB(10, 20, 30))
+
+	tests := []struct {
+		profile termenv.Profile
+		// want are the sequences selecting red on blue and the default
+		// foreground on dark blue.
+		want []string
+	}{
+		{termenv.Ascii, nil},
+		{termenv.ANSI, []string{"\033[0;91;104m", "\033[0;40m"}},
+		{termenv.ANSI256, []string{"\033[0;38;5;196;48;5;21m", "\033[0;48;5;232m"}},
+		{termenv.TrueColor, []string{"\033[0;38;2;255;0;0;48;2;0;0;255m", "\033[0;48;2;10;20;30m"}},
+	}
+
+	s, m, out := newTestScreen(4, 2)
+	s.SetFullRedraw(true)
+	for _, tt := range tests {
+		t.Run(tt.profile.Name(), func(t *testing.T) {
+			// Switching profiles drops the sequences cached for the last.
+			s.SetColorProfile(tt.profile)
+			present(m, frame)
+			out.Reset()
+			if err := s.DrawScreen(); err != nil {
+				t.Fatalf("DrawScreen: %v", err)
+			}
+
+			var want []string
+			for _, seq := range tt.want {
+				want = append(want, seq, "\033[0m")
+			}
+			if got := sgrSequence.FindAllString(out.String(), -1); strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("output selects colors with %q, want %q", got, want)
+			}
+			text := strings.Replace(sgrSequence.ReplaceAllString(out.String(), ""), "\033[2J", "", 1)
+			if text != "\033[Hab  \n  cd\n" {
+				t.Errorf("output shows %q without colors", text)
+			}
+			if tt.profile == termenv.Ascii && len(s.sgr) != 0 {
+				t.Errorf("Ascii cached %d sequences", len(s.sgr))
+			}
+			// The default colors after red on blue are cached too.
+			if tt.profile != termenv.Ascii && len(s.sgr) != 3 {
+				t.Errorf("%d sequences cached, want one per color pair", len(s.sgr))
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package terminal
+
+import (
+	"context"
+	"os"
+)
+
+// WatchResize returns a channel that never delivers: resize notifications
+// are not supported on this platform.
+func WatchResize(ctx context.Context, f *os.File) <-chan Size {
+	return make(chan Size)
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// WatchResize sends the new size of the terminal on f every time the
+// window changes (SIGWINCH) until ctx is done. Only the latest size is
+// kept if the receiver falls behind.
+func WatchResize(ctx context.Context, f *os.File) <-chan Size {
+	sizes := make(chan Size, 1)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+			}
+
+			size, err := GetSize(f)
+			if err != nil {
+				continue
+			}
+			// Replace a size the receiver has not picked up yet.
+			select {
+			case <-sizes:
+			default:
+			}
+			sizes <- size
+		}
+	}()
+	return sizes
+}
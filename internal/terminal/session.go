@@ -0,0 +1,51 @@
+package terminal
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	enterSequence = "\033[?1049h" + // switch to the alternate screen
+		"\033[?25l" + // hide the cursor
+		"\033[?7l" + // disable line wrap
+		"\033[2J\033[H"
+	restoreSequence = "\033[0m" + // reset colors
+		"\033[?7h" + // enable line wrap
+		"\033[?25h" + // show the cursor
+		"\033[?1049l" // back to the main screen
+)
+
+// Session is a terminal switched to full-screen drawing: the alternate
+// screen, so the scrollback is left alone, with the cursor hidden and line
+// wrap disabled.
+type Session struct {
+	out  io.Writer
+	once sync.Once
+}
+
+// Enter switches the terminal on out to full-screen drawing. The caller
+// must call Restore on every way out, including panics.
+func Enter(out io.Writer) (*Session, error) {
+	if _, err := io.WriteString(out, enterSequence); err != nil {
+		return nil, err
+	}
+	return &Session{out: out}, nil
+}
+
+// Restore puts the terminal back as it was before Enter. It is safe to
+// call more than once and from several goroutines; only the first call
+// writes anything.
+func (s *Session) Restore() {
+	s.once.Do(func() {
+		io.WriteString(s.out, restoreSequence)
+	})
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
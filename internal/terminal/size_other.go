@@ -0,0 +1,10 @@
+//go:build !unix
+
+package terminal
+
+import "os"
+
+// GetSize returns the size of the terminal on f.
+func GetSize(f *os.File) (Size, error) {
+	return Size{}, ErrUnsupported
+}
@@ -0,0 +1,60 @@
+package terminal
+
+/**
+ * Queries the terminal the program is attached to.
+ *
+ * @param Cols         number of character columns
+ * @param Rows         number of character rows
+ * @param PixelWidth   width of the text area in pixels, 0 if not reported
+ * @param PixelHeight  height of the text area in pixels, 0 if not reported
+ *
+ * Sizes come from the TIOCGWINSZ ioctl on unix systems, and WatchResize
+ * reports changes on SIGWINCH; elsewhere GetSize returns ErrUnsupported and
+ * no resizes are reported. Many terminals report the cell grid but leave
+ * the pixel size at zero, in which case the cell aspect ratio is unknown.
+ *
+ * Session (see session.go) switches the terminal to full-screen drawing and
+ * restores it afterwards.
+ */
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrUnsupported is returned where the terminal cannot be queried.
+var ErrUnsupported = errors.New("terminal: not supported on this platform")
+
+type Size struct {
+	Cols        int
+	Rows        int
+	PixelWidth  int
+	PixelHeight int
+}
+
+// Fit returns the largest frame, in cells, that can be drawn without
+// scrolling; the last row is left for the newline ending the frame.
+func (s Size) Fit() (cols, rows int) {
+	return s.Cols, s.Rows - 1
+}
+
+// CellAspect returns the width of one cell divided by its height and
+// whether the terminal reported enough to know it.
+func (s Size) CellAspect() (float64, bool) {
+	if s.Cols <= 0 || s.Rows <= 0 || s.PixelWidth <= 0 || s.PixelHeight <= 0 {
+		return 0, false
+	}
+	cellWidth := float64(s.PixelWidth) / float64(s.Cols)
+	cellHeight := float64(s.PixelHeight) / float64(s.Rows)
+	return cellWidth / cellHeight, true
+}
+
+// DetectCellAspect returns the cell aspect ratio of the terminal on f, or
+// false if f is not a terminal or does not report its pixel size.
+func DetectCellAspect(f *os.File) (float64, bool) {
+	size, err := GetSize(f)
+	if err != nil {
+		return 0, false
+	}
+	return size.CellAspect()
+}